@@ -0,0 +1,172 @@
+// Package apierr defines the error responses of the API.
+//
+// Every error response has the same shape:
+//
+//	{"success": false, "code": "FOOD_NOT_FOUND", "error": "Food not found",
+//	 "details": [{"field": "price", "code": "INVALID_PRICE", "message": "..."}],
+//	 "request_id": "..."}
+//
+// code and the codes in details are stable and meant for programs; error and
+// message are localized from Accept-Language for people. Internal errors are
+// logged with their cause but answered with INTERNAL_ERROR only.
+package apierr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error is an API error. Args fill the placeholders of the code's message.
+type Error struct {
+	Status  int
+	Code    Code
+	Args    []any
+	Details []Detail
+	// Data is sent along for errors the client can act on, such as the
+	// number of foods that keep a category from being deleted.
+	Data  any
+	cause error
+}
+
+// Detail describes one invalid field.
+type Detail struct {
+	Field string
+	Code  Code
+	Args  []any
+	// fallback is used when the catalog has no message for Code.
+	fallback string
+}
+
+func New(status int, code Code, args ...any) *Error {
+	return &Error{Status: status, Code: code, Args: args}
+}
+
+func NotFound(code Code) *Error {
+	return New(http.StatusNotFound, code)
+}
+
+func Forbidden(code Code) *Error {
+	return New(http.StatusForbidden, code)
+}
+
+func Conflict(code Code, args ...any) *Error {
+	return New(http.StatusConflict, code, args...)
+}
+
+// PreconditionFailed rejects a write whose If-Match header names an older
+// version of the record.
+func PreconditionFailed(code Code) *Error {
+	return New(http.StatusPreconditionFailed, code)
+}
+
+func Unauthorized(code Code) *Error {
+	return New(http.StatusUnauthorized, code)
+}
+
+// BadRequest is a client error that is not about a particular field.
+func BadRequest(code Code, args ...any) *Error {
+	return New(http.StatusBadRequest, code, args...)
+}
+
+// Invalid reports invalid fields.
+func Invalid(details ...Detail) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeValidationFailed, Details: details}
+}
+
+// Field is shorthand for Invalid with a single field.
+func Field(field string, code Code, args ...any) *Error {
+	return Invalid(Detail{Field: field, Code: code, Args: args})
+}
+
+// Internal hides cause from the client; it is only logged.
+func Internal(cause error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, cause: cause}
+}
+
+// WithData attaches data to the response.
+func (e *Error) WithData(data any) *Error {
+	e.Data = data
+	return e
+}
+
+func (e *Error) Error() string {
+	message := e.message(English)
+	if e.cause != nil {
+		return message + ": " + e.cause.Error()
+	}
+	if len(e.Details) > 0 {
+		return message + ": " + e.Details[0].Field + ": " + e.Details[0].message(English)
+	}
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+func (e *Error) message(lang Language) string {
+	return format(lang, e.Code, string(e.Code), e.Args)
+}
+
+func (d Detail) message(lang Language) string {
+	return format(lang, d.Code, d.fallback, d.Args)
+}
+
+// FieldError is implemented by validation errors of packages that cannot
+// depend on apierr, such as model.ValidationError.
+type FieldError interface {
+	error
+	ErrorField() string
+	ErrorCode() string
+	ErrorArgs() []any
+}
+
+// From turns any error into an *Error: API errors are kept, field errors
+// become validation errors and everything else is internal.
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	var fieldErr FieldError
+	if errors.As(err, &fieldErr) {
+		return Invalid(Detail{
+			Field:    fieldErr.ErrorField(),
+			Code:     Code(fieldErr.ErrorCode()),
+			Args:     fieldErr.ErrorArgs(),
+			fallback: fieldErr.Error(),
+		})
+	}
+	if err == nil {
+		err = errors.New("unknown error")
+	}
+	return Internal(err)
+}
+
+// At turns err into an *Error like From and prefixes the fields of its
+// details with prefix, for errors of one element of a list.
+func At(prefix string, err error) *Error {
+	e := From(err)
+	if len(e.Details) == 0 {
+		return e
+	}
+	prefixed := *e
+	prefixed.Details = make([]Detail, len(e.Details))
+	for i, d := range e.Details {
+		d.Field = prefix + "." + d.Field
+		prefixed.Details[i] = d
+	}
+	return &prefixed
+}
+
+// Wrap keeps API and field errors and wraps anything else as an internal
+// error with context for the log.
+func Wrap(err error, context string) *Error {
+	var apiErr *Error
+	var fieldErr FieldError
+	if errors.As(err, &apiErr) || errors.As(err, &fieldErr) {
+		return From(err)
+	}
+	return Internal(fmt.Errorf("%s: %w", context, err))
+}
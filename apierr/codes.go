@@ -0,0 +1,532 @@
+package apierr
+
+import "fmt"
+
+// Code identifies an error for programs. Codes never change once published.
+type Code string
+
+const (
+	CodeInternal           Code = "INTERNAL_ERROR"
+	CodeValidationFailed   Code = "VALIDATION_FAILED"
+	CodeInvalidBody        Code = "INVALID_BODY"
+	CodeUnauthorized       Code = "UNAUTHORIZED"
+	CodeInvalidToken       Code = "INVALID_TOKEN"
+	CodeCafeAccessRequired Code = "CAFE_ACCESS_REQUIRED"
+	CodeInvalidCredentials Code = "INVALID_CREDENTIALS"
+
+	CodeCafeNotFound          Code = "CAFE_NOT_FOUND"
+	CodeCategoryNotFound      Code = "CATEGORY_NOT_FOUND"
+	CodeFoodNotFound          Code = "FOOD_NOT_FOUND"
+	CodeModifierGroupNotFound Code = "MODIFIER_GROUP_NOT_FOUND"
+	CodePriceChangeNotFound   Code = "PRICE_CHANGE_NOT_FOUND"
+	CodePromotionNotFound     Code = "PROMOTION_NOT_FOUND"
+	CodeTagNotFound           Code = "TAG_NOT_FOUND"
+
+	CodeCategoryForbidden  Code = "CATEGORY_FORBIDDEN"
+	CodeFoodForbidden      Code = "FOOD_FORBIDDEN"
+	CodeTagForbidden       Code = "TAG_FORBIDDEN"
+	CodePromotionForbidden Code = "PROMOTION_FORBIDDEN"
+
+	CodeCategoryGone          Code = "CATEGORY_GONE"
+	CodeParentCategoryGone    Code = "PARENT_CATEGORY_GONE"
+	CodeCategoryNotEmpty      Code = "CATEGORY_NOT_EMPTY"
+	CodeCategoryInUse         Code = "CATEGORY_IN_USE"
+	CodeFoodInUse             Code = "FOOD_IN_USE"
+	CodeFoodInCombo           Code = "FOOD_IN_COMBO"
+	CodeTagCodeTaken          Code = "TAG_CODE_TAKEN"
+	CodePriceChangeNotPending Code = "PRICE_CHANGE_NOT_PENDING"
+	CodeFoodUnavailable       Code = "FOOD_UNAVAILABLE"
+	CodeVersionMismatch       Code = "VERSION_MISMATCH"
+
+	CodeExcelUnreadable  Code = "EXCEL_UNREADABLE"
+	CodeExcelNoRows      Code = "EXCEL_NO_ROWS"
+	CodeExcelNoValidRows Code = "EXCEL_NO_VALID_ROWS"
+
+	// Codes of invalid fields, used in details.
+	CodeRequired               Code = "REQUIRED"
+	CodeInvalidValue           Code = "INVALID_VALUE"
+	CodeInvalidID              Code = "INVALID_ID"
+	CodeInvalidDateTime        Code = "INVALID_DATETIME"
+	CodeInvalidBoolean         Code = "INVALID_BOOLEAN"
+	CodeMinValue               Code = "MIN_VALUE"
+	CodeMaxValue               Code = "MAX_VALUE"
+	CodeOutOfRange             Code = "OUT_OF_RANGE"
+	CodeMustBeFuture           Code = "MUST_BE_FUTURE"
+	CodeInvalidPrice           Code = "INVALID_PRICE"
+	CodeInvalidAmount          Code = "INVALID_AMOUNT"
+	CodeInvalidPercent         Code = "INVALID_PERCENT"
+	CodeNameRequired           Code = "NAME_REQUIRED"
+	CodeEmptyList              Code = "EMPTY_LIST"
+	CodeFileTooLarge           Code = "FILE_TOO_LARGE"
+	CodeInvalidFileType        Code = "INVALID_FILE_TYPE"
+	CodeInvalidCategory        Code = "INVALID_CATEGORY"
+	CodeInvalidTag             Code = "INVALID_TAG"
+	CodeInvalidPromotionTarget Code = "INVALID_PROMOTION_TARGET"
+	CodeInvalidComboOption     Code = "INVALID_COMBO_OPTION"
+	CodeComboContainsItself    Code = "COMBO_CONTAINS_ITSELF"
+	CodeInvalidTimezone        Code = "INVALID_TIMEZONE"
+	CodeInvalidCurrency        Code = "INVALID_CURRENCY"
+	CodeInvalidTagCode         Code = "INVALID_TAG_CODE"
+	CodeInvalidChoice          Code = "INVALID_CHOICE"
+	CodeInvalidParent          Code = "INVALID_PARENT"
+	CodeCategoryCycle          Code = "CATEGORY_CYCLE"
+	CodeCategoryTooDeep        Code = "CATEGORY_TOO_DEEP"
+	CodeInvalidReassign        Code = "INVALID_REASSIGN"
+	CodeInvalidReorder         Code = "INVALID_REORDER"
+	CodeFoodNotInCafe          Code = "FOOD_NOT_IN_CAFE"
+	CodeNotACombo              Code = "NOT_A_COMBO"
+
+	// Codes of model.ValidationError.
+	CodeNegative            Code = "NEGATIVE"
+	CodeMustBeAfter         Code = "MUST_BE_AFTER"
+	CodeTargetRequired      Code = "TARGET_REQUIRED"
+	CodeBundleTooSmall      Code = "BUNDLE_TOO_SMALL"
+	CodeInvalidPercentValue Code = "INVALID_PERCENT_VALUE"
+	CodeMinExceedsMax       Code = "MIN_EXCEEDS_MAX"
+	CodeMinExceedsOptions   Code = "MIN_EXCEEDS_OPTIONS"
+	CodeTooManyDefaults     Code = "TOO_MANY_DEFAULTS"
+	CodeOptionSelectedTwice Code = "OPTION_SELECTED_TWICE"
+	CodeTooFewOptions       Code = "TOO_FEW_OPTIONS"
+	CodeTooManyOptions      Code = "TOO_MANY_OPTIONS"
+	CodeUnknownOptions      Code = "UNKNOWN_OPTIONS"
+	CodeUnknownComboSlot    Code = "UNKNOWN_COMBO_SLOT"
+	CodeComboPickCount      Code = "COMBO_PICK_COUNT"
+	CodeNestedCombo         Code = "NESTED_COMBO"
+	CodeInvalidComboPick    Code = "INVALID_COMBO_PICK"
+	CodeInvalidWeekday      Code = "INVALID_WEEKDAY"
+	CodeInvalidTime         Code = "INVALID_TIME"
+)
+
+type messages map[Language]string
+
+// catalog holds the message of every code. Placeholders are filled with the
+// error's Args in order.
+var catalog = map[Code]messages{
+	CodeInternal: {
+		English: "Something went wrong, please try again later",
+		Russian: "Что-то пошло не так, попробуйте позже",
+		Turkmen: "Näsazlyk ýüze çykdy, biraz soňrak synanyşyň",
+	},
+	CodeValidationFailed: {
+		English: "Some fields are invalid",
+		Russian: "Некоторые поля заполнены неверно",
+		Turkmen: "Käbir meýdançalar nädogry doldurylan",
+	},
+	CodeInvalidBody: {
+		English: "The request body is malformed",
+		Russian: "Некорректное тело запроса",
+		Turkmen: "Soragyň mazmuny nädogry",
+	},
+	CodeUnauthorized: {
+		English: "Authentication required",
+		Russian: "Требуется авторизация",
+		Turkmen: "Ulgama girmek gerek",
+	},
+	CodeInvalidToken: {
+		English: "Invalid or expired token",
+		Russian: "Недействительный или просроченный токен",
+		Turkmen: "Token nädogry ýa-da möhleti geçen",
+	},
+	CodeCafeAccessRequired: {
+		English: "Cafe access required",
+		Russian: "Требуется доступ кафе",
+		Turkmen: "Kafe hasaby gerek",
+	},
+	CodeInvalidCredentials: {
+		English: "Invalid login credentials",
+		Russian: "Неверный логин или пароль",
+		Turkmen: "Login ýa-da açar söz nädogry",
+	},
+
+	CodeCafeNotFound: {
+		English: "Cafe not found",
+		Russian: "Кафе не найдено",
+		Turkmen: "Kafe tapylmady",
+	},
+	CodeCategoryNotFound: {
+		English: "Category not found",
+		Russian: "Категория не найдена",
+		Turkmen: "Kategoriýa tapylmady",
+	},
+	CodeFoodNotFound: {
+		English: "Food not found",
+		Russian: "Блюдо не найдено",
+		Turkmen: "Nahar tapylmady",
+	},
+	CodeModifierGroupNotFound: {
+		English: "Modifier group not found",
+		Russian: "Группа модификаторов не найдена",
+		Turkmen: "Goşundy topary tapylmady",
+	},
+	CodePriceChangeNotFound: {
+		English: "Price change not found",
+		Russian: "Изменение цены не найдено",
+		Turkmen: "Baha üýtgetmesi tapylmady",
+	},
+	CodePromotionNotFound: {
+		English: "Promotion not found",
+		Russian: "Акция не найдена",
+		Turkmen: "Aksiýa tapylmady",
+	},
+	CodeTagNotFound: {
+		English: "Tag not found",
+		Russian: "Метка не найдена",
+		Turkmen: "Bellik tapylmady",
+	},
+
+	CodeCategoryForbidden: {
+		English: "This category belongs to another cafe",
+		Russian: "Эта категория принадлежит другому кафе",
+		Turkmen: "Bu kategoriýa başga kafä degişli",
+	},
+	CodeFoodForbidden: {
+		English: "This food belongs to another cafe",
+		Russian: "Это блюдо принадлежит другому кафе",
+		Turkmen: "Bu nahar başga kafä degişli",
+	},
+	CodeTagForbidden: {
+		English: "This tag cannot be changed by your cafe",
+		Russian: "Ваше кафе не может изменить эту метку",
+		Turkmen: "Bu belligi siziň kafeňiz üýtgedip bilmeýär",
+	},
+	CodePromotionForbidden: {
+		English: "This promotion belongs to another cafe",
+		Russian: "Эта акция принадлежит другому кафе",
+		Turkmen: "Bu aksiýa başga kafä degişli",
+	},
+
+	CodeCategoryGone: {
+		English: "The category no longer exists",
+		Russian: "Категория больше не существует",
+		Turkmen: "Kategoriýa indi ýok",
+	},
+	CodeParentCategoryGone: {
+		English: "The parent category no longer exists",
+		Russian: "Родительская категория больше не существует",
+		Turkmen: "Esasy kategoriýa indi ýok",
+	},
+	CodeCategoryNotEmpty: {
+		English: "Category still has %d subcategories and %d foods; pass reassign_to to move them",
+		Russian: "В категории ещё %d подкатегорий и %d блюд; укажите reassign_to, чтобы перенести их",
+		Turkmen: "Kategoriýada entek %d bölümçe we %d nahar bar; olary göçürmek üçin reassign_to görkeziň",
+	},
+	CodeCategoryInUse: {
+		English: "Category is still offered by %d promotions and %d combos; remove it from them first",
+		Russian: "Категория ещё входит в %d акций и %d комбо; сначала уберите её оттуда",
+		Turkmen: "Kategoriýa entek %d aksiýada we %d kombo-da bar; ilki olardan aýyryň",
+	},
+	CodeFoodInUse: {
+		English: "Food is still offered by %d promotions and %d combos; remove it from them first",
+		Russian: "Блюдо ещё входит в %d акций и %d комбо; сначала уберите его оттуда",
+		Turkmen: "Nahar entek %d aksiýada we %d kombo-da bar; ilki olardan aýyryň",
+	},
+	CodeFoodInCombo: {
+		English: "Food is offered by %d other combos and cannot become a combo itself",
+		Russian: "Блюдо входит в %d других комбо и не может само стать комбо",
+		Turkmen: "Nahar başga %d kombo-da bar we özi kombo bolup bilmeýär",
+	},
+	CodeTagCodeTaken: {
+		English: "A tag with this code already exists",
+		Russian: "Метка с таким кодом уже существует",
+		Turkmen: "Bu kodly bellik eýýäm bar",
+	},
+	CodePriceChangeNotPending: {
+		English: "Only pending price changes can be cancelled",
+		Russian: "Отменить можно только ожидающие изменения цены",
+		Turkmen: "Diňe garaşylýan baha üýtgetmelerini ýatyryp bolýar",
+	},
+	CodeFoodUnavailable: {
+		English: "Food %d is not available right now",
+		Russian: "Блюдо %d сейчас недоступно",
+		Turkmen: "%d belgili nahar häzir elýeterli däl",
+	},
+	CodeVersionMismatch: {
+		English: "This record was changed by someone else, reload it and try again",
+		Russian: "Запись была изменена кем-то другим, обновите её и попробуйте снова",
+		Turkmen: "Bu ýazgy başga biri tarapyndan üýtgedildi, täzeden ýükläp gaýtadan synanyşyň",
+	},
+
+	CodeExcelUnreadable: {
+		English: "The file could not be read as an Excel workbook",
+		Russian: "Не удалось прочитать файл как книгу Excel",
+		Turkmen: "Faýly Excel kitaby hökmünde okap bolmady",
+	},
+	CodeExcelNoRows: {
+		English: "Excel must have at least one row of data",
+		Russian: "В файле Excel должна быть хотя бы одна строка данных",
+		Turkmen: "Excel faýlynda iň bolmanda bir maglumat setiri bolmaly",
+	},
+	CodeExcelNoValidRows: {
+		English: "No valid rows found",
+		Russian: "Не найдено ни одной корректной строки",
+		Turkmen: "Dogry setir tapylmady",
+	},
+
+	CodeRequired: {
+		English: "This field is required",
+		Russian: "Обязательное поле",
+		Turkmen: "Bu meýdança hökmany",
+	},
+	CodeInvalidValue: {
+		English: "Invalid value",
+		Russian: "Недопустимое значение",
+		Turkmen: "Nädogry maglumat",
+	},
+	CodeInvalidID: {
+		English: "Must be a numeric ID",
+		Russian: "Должен быть числовым идентификатором",
+		Turkmen: "San görnüşindäki ID bolmaly",
+	},
+	CodeInvalidDateTime: {
+		English: "Expected a date and time in RFC 3339 format",
+		Russian: "Ожидаются дата и время в формате RFC 3339",
+		Turkmen: "Sene we wagt RFC 3339 görnüşinde bolmaly",
+	},
+	CodeInvalidBoolean: {
+		English: "Must be true or false",
+		Russian: "Должно быть true или false",
+		Turkmen: "true ýa-da false bolmaly",
+	},
+	CodeMinValue: {
+		English: "Must be at least %v",
+		Russian: "Должно быть не меньше %v",
+		Turkmen: "Iň azyndan %v bolmaly",
+	},
+	CodeMaxValue: {
+		English: "Must be at most %v",
+		Russian: "Должно быть не больше %v",
+		Turkmen: "Iň köp %v bolmaly",
+	},
+	CodeOutOfRange: {
+		English: "Must be between %v and %v",
+		Russian: "Должно быть от %v до %v",
+		Turkmen: "%v bilen %v aralygynda bolmaly",
+	},
+	CodeMustBeFuture: {
+		English: "Must be in the future",
+		Russian: "Должно быть в будущем",
+		Turkmen: "Geljekde bolmaly",
+	},
+	CodeInvalidPrice: {
+		English: "Must be a positive amount with up to two decimals",
+		Russian: "Должна быть положительной суммой не более чем с двумя знаками после запятой",
+		Turkmen: "Iki onluk belgä çenli položitel mukdar bolmaly",
+	},
+	CodeInvalidAmount: {
+		English: "Must be an amount with up to two decimals",
+		Russian: "Должна быть суммой не более чем с двумя знаками после запятой",
+		Turkmen: "Iki onluk belgä çenli mukdar bolmaly",
+	},
+	CodeInvalidPercent: {
+		English: "Must be a non-zero number greater than -100 with up to two decimals",
+		Russian: "Должно быть ненулевым числом больше -100 не более чем с двумя знаками после запятой",
+		Turkmen: "-100-den uly, nola deň bolmadyk, iki onluk belgä çenli san bolmaly",
+	},
+	CodeNameRequired: {
+		English: "At least one name (TM, RU or EN) is required",
+		Russian: "Нужно указать хотя бы одно название (TM, RU или EN)",
+		Turkmen: "Iň bolmanda bir at (TM, RU ýa-da EN) hökmany",
+	},
+	CodeEmptyList: {
+		English: "At least one item is required",
+		Russian: "Нужен хотя бы один элемент",
+		Turkmen: "Iň bolmanda bir element gerek",
+	},
+	CodeFileTooLarge: {
+		English: "The file exceeds the %dMB limit",
+		Russian: "Файл превышает лимит %d МБ",
+		Turkmen: "Faýl %dMB çäginden uly",
+	},
+	CodeInvalidFileType: {
+		English: "Only JPG, JPEG and PNG files are allowed",
+		Russian: "Допускаются только файлы JPG, JPEG и PNG",
+		Turkmen: "Diňe JPG, JPEG we PNG faýllary rugsat edilýär",
+	},
+	CodeInvalidCategory: {
+		English: "The category does not exist or belongs to another cafe",
+		Russian: "Категория не существует или принадлежит другому кафе",
+		Turkmen: "Kategoriýa ýok ýa-da başga kafä degişli",
+	},
+	CodeInvalidTag: {
+		English: "A tag does not exist or is not available to your cafe",
+		Russian: "Метка не существует или недоступна вашему кафе",
+		Turkmen: "Bellik ýok ýa-da siziň kafeňiz üçin elýeterli däl",
+	},
+	CodeInvalidPromotionTarget: {
+		English: "A food or category does not exist or belongs to another cafe",
+		Russian: "Блюдо или категория не существует или принадлежит другому кафе",
+		Turkmen: "Nahar ýa-da kategoriýa ýok ýa-da başga kafä degişli",
+	},
+	CodeInvalidComboOption: {
+		English: "Combo options must be single foods of your cafe",
+		Russian: "Варианты комбо должны быть отдельными блюдами вашего кафе",
+		Turkmen: "Kombo saýlawlary siziň kafeňiziň ýeke naharlary bolmaly",
+	},
+	CodeComboContainsItself: {
+		English: "A combo cannot contain itself",
+		Russian: "Комбо не может содержать само себя",
+		Turkmen: "Kombo özüni öz içine alyp bilmeýär",
+	},
+	CodeInvalidTimezone: {
+		English: "Unknown timezone",
+		Russian: "Неизвестный часовой пояс",
+		Turkmen: "Näbelli wagt guşaklygy",
+	},
+	CodeInvalidCurrency: {
+		English: "Must be one of the supported currencies: %s",
+		Russian: "Должна быть одной из поддерживаемых валют: %s",
+		Turkmen: "Goldanylýan pul birlikleriniň biri bolmaly: %s",
+	},
+	CodeInvalidTagCode: {
+		English: "Must consist of lowercase letters, digits and underscores",
+		Russian: "Может содержать только строчные буквы, цифры и подчёркивания",
+		Turkmen: "Diňe kiçi harplardan, sanlardan we aşaky çyzyklardan ybarat bolmaly",
+	},
+	CodeInvalidChoice: {
+		English: "Must be one of: %s",
+		Russian: "Должно быть одним из: %s",
+		Turkmen: "Şulardan biri bolmaly: %s",
+	},
+	CodeInvalidParent: {
+		English: "The parent category does not exist or belongs to another cafe",
+		Russian: "Родительская категория не существует или принадлежит другому кафе",
+		Turkmen: "Esasy kategoriýa ýok ýa-da başga kafä degişli",
+	},
+	CodeCategoryCycle: {
+		English: "A category cannot be moved under itself or its subcategories",
+		Russian: "Категорию нельзя переместить в саму себя или в её подкатегории",
+		Turkmen: "Kategoriýany öz içine ýa-da öz bölümçeleriniň içine geçirip bolmaýar",
+	},
+	CodeCategoryTooDeep: {
+		English: "Categories can be nested at most %d levels deep",
+		Russian: "Категории могут быть вложены не более чем на %d уровня",
+		Turkmen: "Kategoriýalar iň köp %d dereje içme-iç bolup biler",
+	},
+	CodeInvalidReassign: {
+		English: "Must be another category of your cafe outside the one being deleted",
+		Russian: "Должна быть другой категорией вашего кафе вне удаляемой",
+		Turkmen: "Pozulýan kategoriýanyň daşynda siziň kafeňiziň başga kategoriýasy bolmaly",
+	},
+	CodeInvalidReorder: {
+		English: "Every ID must be one of your items and appear only once",
+		Russian: "Каждый идентификатор должен принадлежать вашему кафе и встречаться один раз",
+		Turkmen: "Her ID siziň kafeňize degişli bolmaly we diňe bir gezek gabat gelmeli",
+	},
+	CodeFoodNotInCafe: {
+		English: "Food %d is not on this cafe's menu",
+		Russian: "Блюда %d нет в меню этого кафе",
+		Turkmen: "%d belgili nahar bu kafeniň menýusynda ýok",
+	},
+	CodeNotACombo: {
+		English: "Food %d is not a combo",
+		Russian: "Блюдо %d не является комбо",
+		Turkmen: "%d belgili nahar kombo däl",
+	},
+
+	CodeNegative: {
+		English: "Must not be negative",
+		Russian: "Не может быть отрицательным",
+		Turkmen: "Otrisatel bolup bilmeýär",
+	},
+	CodeMustBeAfter: {
+		English: "Must be after %s",
+		Russian: "Должно быть позже %s",
+		Turkmen: "%s-den soň bolmaly",
+	},
+	CodeTargetRequired: {
+		English: "Every item needs either a food_id or a category_id",
+		Russian: "Каждому элементу нужен food_id или category_id",
+		Turkmen: "Her elemente food_id ýa-da category_id gerek",
+	},
+	CodeBundleTooSmall: {
+		English: "A bundle needs at least two items",
+		Russian: "В наборе должно быть не меньше двух позиций",
+		Turkmen: "Toplumda iň bolmanda iki zat bolmaly",
+	},
+	CodeInvalidPercentValue: {
+		English: "Must be greater than 0 and at most 100",
+		Russian: "Должно быть больше 0 и не больше 100",
+		Turkmen: "0-dan uly we 100-den köp bolmaly däl",
+	},
+	CodeMinExceedsMax: {
+		English: "min_select must not exceed max_select",
+		Russian: "min_select не может быть больше max_select",
+		Turkmen: "min_select max_select-den uly bolup bilmeýär",
+	},
+	CodeMinExceedsOptions: {
+		English: "min_select must not exceed the number of options",
+		Russian: "min_select не может быть больше количества вариантов",
+		Turkmen: "min_select saýlawlaryň sanyndan uly bolup bilmeýär",
+	},
+	CodeTooManyDefaults: {
+		English: "More default options than max_select allows",
+		Russian: "Вариантов по умолчанию больше, чем позволяет max_select",
+		Turkmen: "Deslapky saýlawlar max_select rugsat berýänden köp",
+	},
+	CodeOptionSelectedTwice: {
+		English: "Option %d is selected twice",
+		Russian: "Вариант %d выбран дважды",
+		Turkmen: "%d belgili saýlaw iki gezek saýlandy",
+	},
+	CodeTooFewOptions: {
+		English: "Choose at least %d option(s) in %q",
+		Russian: "Выберите не меньше %d вариант(ов) в %q",
+		Turkmen: "%[2]q içinde iň bolmanda %[1]d saýlaw saýlaň",
+	},
+	CodeTooManyOptions: {
+		English: "Choose at most %d option(s) in %q",
+		Russian: "Выберите не больше %d вариант(ов) в %q",
+		Turkmen: "%[2]q içinde iň köp %[1]d saýlaw saýlaň",
+	},
+	CodeUnknownOptions: {
+		English: "Some options do not belong to this food",
+		Russian: "Некоторые варианты не относятся к этому блюду",
+		Turkmen: "Käbir saýlawlar bu nahara degişli däl",
+	},
+	CodeUnknownComboSlot: {
+		English: "Combo slot %d does not belong to this food",
+		Russian: "Позиция комбо %d не относится к этому блюду",
+		Turkmen: "%d belgili kombo orny bu nahara degişli däl",
+	},
+	CodeComboPickCount: {
+		English: "Pick %d item(s) for %q",
+		Russian: "Выберите %d позиций для %q",
+		Turkmen: "%[2]q üçin %[1]d zat saýlaň",
+	},
+	CodeNestedCombo: {
+		English: "A combo cannot contain another combo",
+		Russian: "Комбо не может содержать другое комбо",
+		Turkmen: "Kombo başga komboný öz içine alyp bilmeýär",
+	},
+	CodeInvalidComboPick: {
+		English: "Food %d cannot be picked for %q",
+		Russian: "Блюдо %d нельзя выбрать для %q",
+		Turkmen: "%[1]d belgili nahary %[2]q üçin saýlap bolmaýar",
+	},
+	CodeInvalidWeekday: {
+		English: "Must be between 0 (Sunday) and 6 (Saturday)",
+		Russian: "Должно быть от 0 (воскресенье) до 6 (суббота)",
+		Turkmen: "0 (ýekşenbe) bilen 6 (şenbe) aralygynda bolmaly",
+	},
+	CodeInvalidTime: {
+		English: "Expected a time as HH:MM",
+		Russian: "Ожидается время в формате ЧЧ:ММ",
+		Turkmen: "Wagt SS:MM görnüşinde bolmaly",
+	},
+}
+
+// format returns the message of code in lang, falling back to English and
+// then to fallback.
+func format(lang Language, code Code, fallback string, args []any) string {
+	template, ok := catalog[code][lang]
+	if !ok {
+		template, ok = catalog[code][English]
+	}
+	if !ok {
+		return fallback
+	}
+	if len(args) == 0 {
+		return template
+	}
+	return fmt.Sprintf(template, args...)
+}
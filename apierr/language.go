@@ -0,0 +1,31 @@
+package apierr
+
+import "golang.org/x/text/language"
+
+// Language is a language messages are available in.
+type Language string
+
+const (
+	English Language = "en"
+	Russian Language = "ru"
+	Turkmen Language = "tk"
+)
+
+// supported lists the languages in the order of the matcher; English comes
+// first and is used when nothing else matches.
+var supported = []Language{English, Russian, Turkmen}
+
+var matcher = language.NewMatcher([]language.Tag{language.English, language.Russian, language.MustParse("tk")})
+
+// Negotiate picks the best supported language for an Accept-Language header.
+func Negotiate(acceptLanguage string) Language {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return English
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return English
+	}
+	return supported[index]
+}
@@ -0,0 +1,84 @@
+package apierr
+
+import (
+	"cafe/logging"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// Respond writes err as an error response and aborts the request. Internal
+// errors are logged with their cause.
+func Respond(c *gin.Context, err error) {
+	e := From(err)
+	ctx := c.Request.Context()
+	if e.Status >= 500 {
+		slog.ErrorContext(ctx, "request failed", "code", e.Code, "error", e.Error())
+	}
+
+	lang := Negotiate(c.GetHeader("Accept-Language"))
+	body := gin.H{
+		"success": false,
+		"code":    e.Code,
+		"error":   e.message(lang),
+	}
+	if len(e.Details) > 0 {
+		details := make([]gin.H, len(e.Details))
+		for i, d := range e.Details {
+			details[i] = gin.H{"field": d.Field, "code": d.Code, "message": d.message(lang)}
+		}
+		body["details"] = details
+	}
+	if e.Data != nil {
+		body["data"] = e.Data
+	}
+	if id := logging.RequestID(ctx); id != "" {
+		body["request_id"] = id
+	}
+
+	c.Header("Content-Language", string(lang))
+	c.AbortWithStatusJSON(e.Status, body)
+}
+
+// Bind turns an error of ShouldBind into field details where the validator
+// can name the field, and into INVALID_BODY otherwise.
+func Bind(err error) *Error {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return BadRequest(CodeInvalidBody)
+	}
+	details := make([]Detail, len(fieldErrs))
+	for i, fe := range fieldErrs {
+		details[i] = Detail{Field: fieldPath(fe.Namespace())}
+		switch fe.Tag() {
+		case "required":
+			details[i].Code = CodeRequired
+		case "min":
+			details[i].Code, details[i].Args = CodeMinValue, []any{fe.Param()}
+		case "max":
+			details[i].Code, details[i].Args = CodeMaxValue, []any{fe.Param()}
+		default:
+			details[i].Code = CodeInvalidValue
+		}
+	}
+	return Invalid(details...)
+}
+
+var upperRun = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// fieldPath turns Request.Items[0].FoodID into items[0].food_id.
+func fieldPath(namespace string) string {
+	parts := strings.Split(namespace, ".")
+	if len(parts) > 1 {
+		parts = parts[1:]
+	}
+	for i, part := range parts {
+		part = strings.ReplaceAll(part, "ID", "Id")
+		parts[i] = strings.ToLower(upperRun.ReplaceAllString(part, "${1}_${2}"))
+	}
+	return strings.Join(parts, ".")
+}
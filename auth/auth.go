@@ -1,15 +1,26 @@
 package auth
 
 import (
-	"cafe/database"
+	"cafe/apierr"
 	"cafe/model"
 	"cafe/utils"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 	"net/http"
 )
 
-func Login(c *gin.Context) {
+// Handler serves the admin authentication endpoints.
+type Handler struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Handler {
+	return &Handler{db: db}
+}
+
+func (h *Handler) Login(c *gin.Context) {
 	type Request struct {
 		PhoneNumber string `form:"phone_number" binding:"required"`
 		Password    string `form:"password" binding:"required"`
@@ -17,24 +28,24 @@ func Login(c *gin.Context) {
 
 	var req Request
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Telefon belgisi we açar sözi hökmany"})
+		apierr.Respond(c, apierr.Bind(err))
 		return
 	}
 
 	var user model.User
-	if err := database.DB.Where("phone_number = ?", req.PhoneNumber).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Ulanyjy tapylmady"})
+	if err := h.db.WithContext(c.Request.Context()).Where("phone_number = ?", req.PhoneNumber).First(&user).Error; err != nil {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeInvalidCredentials))
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Nädogry açar söz"})
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeInvalidCredentials))
 		return
 	}
 
 	access, refresh, err := utils.GenerateTokens(string(user.Role), user.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token döretmek şowsuz boldy"})
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("token döretmek şowsuz boldy: %w", err)))
 		return
 	}
 
@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"cafe/config"
+	"cafe/database"
+	"cafe/model"
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// cafeRole is the role CafeMiddleware expects in cafe tokens.
+const cafeRole = "cafe"
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+func runCreateAdmin(args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	phone := fs.String("phone", "", "phone number used to log in (required)")
+	email := fs.String("email", "", "email address")
+	firstName := fs.String("first-name", "", "first name")
+	lastName := fs.String("last-name", "", "last name")
+	password := fs.String("password", "", "password; read from stdin when omitted")
+	if err := parseFlags(fs, args, "phone"); err != nil {
+		return err
+	}
+
+	plain, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+	hashed, err := hashPassword(plain)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.InitDatabase(config.Current.DatabaseDSN)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	var count int64
+	if err := db.Model(&model.User{}).Where("phone_number = ?", *phone).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("a user with phone number %s already exists", *phone)
+	}
+
+	user := model.User{
+		PhoneNumber: *phone,
+		Email:       *email,
+		FirstName:   *firstName,
+		LastName:    *lastName,
+		Password:    hashed,
+		Role:        model.Admin,
+		Status:      "active",
+	}
+	if err := db.Create(&user).Error; err != nil {
+		return fmt.Errorf("failed to create admin: %w", err)
+	}
+	fmt.Printf("Admin %d created\n", user.ID)
+	return nil
+}
+
+func runCreateCafe(args []string) error {
+	fs := flag.NewFlagSet("create-cafe", flag.ContinueOnError)
+	login := fs.String("login", "", "login of the cafe account (required)")
+	name := fs.String("name", "", "cafe name (required)")
+	code := fs.String("code", "", "public cafe code")
+	password := fs.String("password", "", "password; read from stdin when omitted")
+	days := fs.Int("days", 30, "length of the first subscription period in days")
+	timezone := fs.String("timezone", model.DefaultTimezone, "IANA timezone of the cafe")
+	currency := fs.String("currency", model.DefaultCurrency, "ISO 4217 currency code")
+	if err := parseFlags(fs, args, "login", "name"); err != nil {
+		return err
+	}
+	if *days < 0 {
+		return errors.New("-days must not be negative")
+	}
+	if _, err := time.LoadLocation(*timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+	if !currencyPattern.MatchString(*currency) {
+		return errors.New("-currency must be a three-letter ISO 4217 code")
+	}
+
+	plain, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+	hashed, err := hashPassword(plain)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.InitDatabase(config.Current.DatabaseDSN)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	var count int64
+	if err := db.Model(&model.Cafe{}).Where("login = ?", *login).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("a cafe with login %s already exists", *login)
+	}
+
+	cafe := model.Cafe{
+		Login:      *login,
+		Password:   hashed,
+		Name:       *name,
+		Code:       *code,
+		UserRole:   cafeRole,
+		ExpiryDate: time.Now().AddDate(0, 0, *days),
+		Timezone:   *timezone,
+		Currency:   *currency,
+	}
+	if err := db.Create(&cafe).Error; err != nil {
+		return fmt.Errorf("failed to create cafe: %w", err)
+	}
+	fmt.Printf("Cafe %d created, subscription ends %s\n", cafe.ID, cafe.ExpiryDate.Format("2006-01-02"))
+	return nil
+}
+
+func runSetCafePassword(args []string) error {
+	fs := flag.NewFlagSet("set-cafe-password", flag.ContinueOnError)
+	login := fs.String("login", "", "login of the cafe account (required)")
+	password := fs.String("password", "", "new password; read from stdin when omitted")
+	if err := parseFlags(fs, args, "login"); err != nil {
+		return err
+	}
+
+	plain, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+	hashed, err := hashPassword(plain)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.InitDatabase(config.Current.DatabaseDSN)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	cafe, err := findCafe(db, *login)
+	if err != nil {
+		return err
+	}
+	if err := db.Model(&cafe).Update("password", hashed).Error; err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	fmt.Printf("Password of cafe %d updated\n", cafe.ID)
+	return nil
+}
+
+// runExtendSubscription adds days to the expiry date, counting from today when
+// the subscription has already run out.
+func runExtendSubscription(args []string) error {
+	fs := flag.NewFlagSet("extend-subscription", flag.ContinueOnError)
+	login := fs.String("login", "", "login of the cafe account (required)")
+	days := fs.Int("days", 30, "number of days to add")
+	if err := parseFlags(fs, args, "login"); err != nil {
+		return err
+	}
+	if *days <= 0 {
+		return errors.New("-days must be positive")
+	}
+
+	db, err := database.InitDatabase(config.Current.DatabaseDSN)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	cafe, err := findCafe(db, *login)
+	if err != nil {
+		return err
+	}
+	from := cafe.ExpiryDate
+	if now := time.Now(); from.Before(now) {
+		from = now
+	}
+	expiry := from.AddDate(0, 0, *days)
+	if err := db.Model(&cafe).Update("expiry_date", expiry).Error; err != nil {
+		return fmt.Errorf("failed to extend subscription: %w", err)
+	}
+	fmt.Printf("Subscription of cafe %d ends %s\n", cafe.ID, expiry.Format("2006-01-02"))
+	return nil
+}
+
+func findCafe(db *gorm.DB, login string) (model.Cafe, error) {
+	var cafe model.Cafe
+	if err := db.Where("login = ?", login).First(&cafe).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return cafe, fmt.Errorf("no cafe with login %s", login)
+		}
+		return cafe, err
+	}
+	return cafe, nil
+}
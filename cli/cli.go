@@ -0,0 +1,119 @@
+// Package cli implements the subcommands of the cafe binary.
+package cli
+
+import (
+	"bufio"
+	"cafe/config"
+	"cafe/logging"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"serve":               {"start the HTTP server (default)", runServe},
+	"migrate":             {"apply, revert or list schema migrations", runMigrate},
+	"create-admin":        {"create an admin user", runCreateAdmin},
+	"create-cafe":         {"create a cafe account", runCreateCafe},
+	"set-cafe-password":   {"set the password of a cafe account", runSetCafePassword},
+	"extend-subscription": {"move the expiry date of a cafe forward", runExtendSubscription},
+	"seed-demo":           {"fill the database with a demo cafe and menu", runSeedDemo},
+	"check-integrity":     {"report and repair orphaned categories and foods", runCheckIntegrity},
+}
+
+// Run executes the subcommand named by args[0]; without arguments it serves.
+func Run(args []string) error {
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(os.Stdout)
+		return nil
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		usage(os.Stderr)
+		return fmt.Errorf("unknown command %q", name)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	config.Current = cfg
+	if err := logging.Setup(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+		return err
+	}
+
+	if err := cmd.run(args); err != nil && !errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return nil
+}
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: cafe <command> [flags]")
+	fmt.Fprintln(w)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-20s %s\n", name, commands[name].summary)
+	}
+}
+
+// parseFlags parses args and checks that every flag in required was given.
+func parseFlags(fs *flag.FlagSet, args []string, required ...string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, name := range required {
+		if !set[name] {
+			return fmt.Errorf("-%s is required", name)
+		}
+	}
+	return nil
+}
+
+// readPassword returns password, or reads one line from stdin when it is
+// empty so that the password does not end up in the shell history.
+func readPassword(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	password = strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("password must not be empty")
+	}
+	return password, nil
+}
+
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
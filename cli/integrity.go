@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"cafe/config"
+	"cafe/database"
+	"flag"
+	"fmt"
+)
+
+// runCheckIntegrity reports rows that break the links between cafes,
+// categories and foods and, with -repair, fixes the ones it can.
+func runCheckIntegrity(args []string) error {
+	fs := flag.NewFlagSet("check-integrity", flag.ContinueOnError)
+	repair := fs.Bool("repair", false, "move orphaned foods to an Uncategorized category and detach orphaned subcategories")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	db, err := database.InitDatabase(config.Current.DatabaseDSN)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	issues, err := database.CheckIntegrity(db)
+	if err != nil {
+		return fmt.Errorf("integrity check failed: %w", err)
+	}
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	fmt.Printf("%d issues found\n", len(issues))
+
+	if !*repair || len(issues) == 0 {
+		return nil
+	}
+
+	repaired, err := database.RepairIntegrity(db)
+	if err != nil {
+		return fmt.Errorf("repair failed: %w", err)
+	}
+	fmt.Printf("%d rows repaired\n", repaired)
+
+	pending, err := database.ValidateForeignKeys(db)
+	if err != nil {
+		return fmt.Errorf("failed to validate foreign keys: %w", err)
+	}
+	for _, name := range pending {
+		fmt.Printf("%s is still not valid, remaining rows need a manual fix\n", name)
+	}
+	return nil
+}
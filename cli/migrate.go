@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"cafe/config"
+	"cafe/database"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+)
+
+var errMigrateUsage = errors.New("usage: cafe migrate [up | down [steps] | status]")
+
+// runMigrate handles `cafe migrate`, which applies, reverts or lists the
+// versioned migrations in database/migrations.
+func runMigrate(args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up", "down", "status":
+	default:
+		return errMigrateUsage
+	}
+
+	db, err := database.Connect(config.Current.DatabaseDSN)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	switch command {
+	case "up":
+		applied, err := database.MigrateUp(db)
+		for _, migration := range applied {
+			slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+		if len(applied) == 0 {
+			slog.Info("Schema is up to date")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return errMigrateUsage
+			}
+			steps = n
+		}
+		reverted, err := database.MigrateDown(db, steps)
+		for _, migration := range reverted {
+			slog.Info("Reverted migration", "version", migration.Version, "name", migration.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+	case "status":
+		status, err := database.GetMigrationStatus(db)
+		if err != nil {
+			return fmt.Errorf("failed to read migrations: %w", err)
+		}
+		for _, s := range status {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, state)
+		}
+	}
+	return nil
+}
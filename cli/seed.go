@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"cafe/config"
+	"cafe/database"
+	"cafe/model"
+	"flag"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type demoFood struct {
+	nameTm, nameRu string
+	price          model.Money
+	tags           []string
+}
+
+type demoCategory struct {
+	nameTm, nameRu, nameEn string
+	foods                  []demoFood
+	children               []demoCategory
+}
+
+var demoMenu = []demoCategory{
+	{
+		nameTm: "Naharlar", nameRu: "Горячие блюда", nameEn: "Main dishes",
+		foods: []demoFood{
+			{"Palow", "Плов", 4500, []string{"halal"}},
+			{"Manty", "Манты", 3500, []string{"halal", "gluten"}},
+			{"Gutap", "Гутап", 1500, []string{"gluten"}},
+		},
+	},
+	{
+		nameTm: "Salatlar", nameRu: "Салаты", nameEn: "Salads",
+		foods: []demoFood{
+			{"Çopan salaty", "Салат пастуший", 2500, []string{"vegan"}},
+			{"Sezar salaty", "Салат Цезарь", 3800, []string{"eggs", "milk", "gluten"}},
+		},
+	},
+	{
+		nameTm: "Içgiler", nameRu: "Напитки", nameEn: "Drinks",
+		children: []demoCategory{
+			{
+				nameTm: "Gyzgyn içgiler", nameRu: "Горячие напитки", nameEn: "Hot drinks",
+				foods: []demoFood{
+					{"Gök çaý", "Зелёный чай", 800, []string{"vegan", "sugar_free"}},
+					{"Kapuçino", "Капучино", 2200, []string{"milk", "vegetarian"}},
+				},
+			},
+			{
+				nameTm: "Sowuk içgiler", nameRu: "Холодные напитки", nameEn: "Cold drinks",
+				foods: []demoFood{
+					{"Limonad", "Лимонад", 1800, []string{"vegan"}},
+					{"Aýran", "Айран", 1000, []string{"milk", "vegetarian"}},
+				},
+			},
+		},
+	},
+}
+
+// runSeedDemo creates a demo cafe with nested categories, tagged foods, a
+// modifier group and a running promotion, so the API can be tried out.
+func runSeedDemo(args []string) error {
+	fs := flag.NewFlagSet("seed-demo", flag.ContinueOnError)
+	login := fs.String("login", "demo", "login of the demo cafe")
+	password := fs.String("password", "demo1234", "password of the demo cafe")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	hashed, err := hashPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.InitDatabase(config.Current.DatabaseDSN)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	var count int64
+	if err := db.Model(&model.Cafe{}).Where("login = ?", *login).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("a cafe with login %s already exists", *login)
+	}
+
+	var tags []model.Tag
+	if err := db.Where("cafe_id IS NULL").Find(&tags).Error; err != nil {
+		return err
+	}
+	tagsByCode := make(map[string]model.Tag, len(tags))
+	for _, tag := range tags {
+		tagsByCode[tag.Code] = tag
+	}
+
+	var cafe model.Cafe
+	foods := 0
+	err = db.Transaction(func(tx *gorm.DB) error {
+		cafe = model.Cafe{
+			Login:      *login,
+			Password:   hashed,
+			Name:       "Demo Cafe",
+			Code:       "DEMO",
+			UserRole:   cafeRole,
+			ExpiryDate: time.Now().AddDate(1, 0, 0),
+		}
+		if err := tx.Create(&cafe).Error; err != nil {
+			return err
+		}
+
+		var plov model.Food
+		var seed func(categories []demoCategory, parentID *uint) error
+		seed = func(categories []demoCategory, parentID *uint) error {
+			for i, demo := range categories {
+				category := model.FoodCategory{
+					CafeId:    cafe.ID,
+					NameTM:    demo.nameTm,
+					NameRU:    demo.nameRu,
+					NameEN:    demo.nameEn,
+					SortOrder: i + 1,
+					ParentID:  parentID,
+				}
+				if err := tx.Create(&category).Error; err != nil {
+					return err
+				}
+
+				for j, demoFood := range demo.foods {
+					food := model.Food{
+						CafeID:     cafe.ID,
+						CategoryID: category.ID,
+						NameTm:     demoFood.nameTm,
+						NameRu:     demoFood.nameRu,
+						Price:      demoFood.price,
+						SortOrder:  j + 1,
+					}
+					for _, code := range demoFood.tags {
+						if tag, ok := tagsByCode[code]; ok {
+							food.Tags = append(food.Tags, tag)
+						}
+					}
+					if err := tx.Create(&food).Error; err != nil {
+						return err
+					}
+					if food.NameTm == "Palow" {
+						plov = food
+					}
+					foods++
+				}
+
+				if err := seed(demo.children, &category.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := seed(demoMenu, nil); err != nil {
+			return err
+		}
+
+		portion := model.ModifierGroup{
+			FoodID:    plov.ID,
+			NameTm:    "Porsiýa",
+			NameRu:    "Порция",
+			MinSelect: 1,
+			MaxSelect: 1,
+			Options: []model.ModifierOption{
+				{NameTm: "Adaty", NameRu: "Обычная", IsDefault: true, SortOrder: 1},
+				{NameTm: "Uly", NameRu: "Большая", PriceDelta: 1500, SortOrder: 2},
+			},
+		}
+		if err := tx.Create(&portion).Error; err != nil {
+			return err
+		}
+
+		promotion := model.Promotion{
+			CafeID:   cafe.ID,
+			NameTm:   "Günortan arzanladyş",
+			NameRu:   "Скидка на обед",
+			Type:     model.PromotionPercent,
+			Value:    1000,
+			IsActive: true,
+			Targets:  []model.PromotionTarget{{FoodID: &plov.ID, Quantity: 1}},
+			Schedules: []model.MenuSchedule{
+				{CafeID: cafe.ID, Weekday: 1, StartTime: "12:00", EndTime: "15:00"},
+				{CafeID: cafe.ID, Weekday: 2, StartTime: "12:00", EndTime: "15:00"},
+				{CafeID: cafe.ID, Weekday: 3, StartTime: "12:00", EndTime: "15:00"},
+				{CafeID: cafe.ID, Weekday: 4, StartTime: "12:00", EndTime: "15:00"},
+				{CafeID: cafe.ID, Weekday: 5, StartTime: "12:00", EndTime: "15:00"},
+			},
+		}
+		return tx.Create(&promotion).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to seed demo data: %w", err)
+	}
+
+	fmt.Printf("Demo cafe %d created with %d foods, login %s\n", cafe.ID, foods, *login)
+	return nil
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"cafe/config"
+	"cafe/database"
+	"cafe/jobs"
+	"cafe/logging"
+	"cafe/metrics"
+	"cafe/route"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := database.InitDatabase(config.Current.DatabaseDSN)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterDB(sqlDB)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	schedulerDone := jobs.StartPriceScheduler(jobsCtx, db, config.Current.PriceSchedulerInterval)
+
+	// Set Gin mode
+	gin.SetMode(config.Current.Mode)
+	if !config.Current.Release() {
+		slog.Info("Running in development mode", "mode", config.Current.Mode)
+	}
+
+	// Initialize router
+	router := gin.New()
+	router.Use(logging.Middleware(), logging.Recovery())
+	router.MaxMultipartMemory = config.Current.MaxMultipartBytes()
+	router.Use(metrics.Middleware())
+
+	// Configure CORS
+	corsConfig := cors.Config{
+		AllowOrigins:     config.Current.AllowedOrigins,
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", "If-None-Match", "If-Modified-Since"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "Last-Modified"},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	}
+	router.Use(cors.New(corsConfig))
+	slog.Debug("CORS configured", "origins", config.Current.AllowedOrigins)
+
+	// Setup routes
+	route.SystemRoutes(router, db)
+	route.CafeRoutes(router, db)
+	slog.Debug("Routes configured")
+
+	// Serve static files
+	if err := os.MkdirAll(config.Current.UploadDir, 0755); err != nil {
+		return fmt.Errorf("failed to create uploads directory: %w", err)
+	}
+	route.UploadRoutes(router)
+
+	frontendPath := "./frontend/build"
+	if _, err := os.Stat(frontendPath); os.IsNotExist(err) {
+		slog.Warn("Frontend build directory not found, static file serving may fail", "path", frontendPath)
+	}
+	router.StaticFS("/static", http.Dir(filepath.Join(frontendPath, "static")))
+	router.NoRoute(func(c *gin.Context) {
+		c.File(filepath.Join(frontendPath, "index.html"))
+	})
+	slog.Debug("Static file serving configured")
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", config.Current.Port),
+		Handler:           router,
+		ReadHeaderTimeout: config.Current.ReadHeaderTimeout,
+		ReadTimeout:       config.Current.ReadTimeout,
+		WriteTimeout:      config.Current.WriteTimeout,
+		IdleTimeout:       config.Current.IdleTimeout,
+		MaxHeaderBytes:    config.Current.MaxHeaderKB << 10,
+	}
+
+	// Start server
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("Starting server", "port", config.Current.Port)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("failed to start server: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	// A second signal skips the drain and exits right away.
+	stop()
+	slog.Info("Shutting down, waiting for requests to finish", "timeout", config.Current.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.Current.ShutdownTimeout)
+	defer cancel()
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		slog.Warn("Requests still running after the shutdown timeout were cut off", "timeout", config.Current.ShutdownTimeout, "error", err)
+		server.Close()
+	}
+
+	stopJobs()
+	select {
+	case <-schedulerDone:
+	case <-shutdownCtx.Done():
+		slog.Warn("Price scheduler did not stop in time")
+	}
+	slog.Info("Server stopped")
+	return nil
+}
@@ -0,0 +1,368 @@
+// Package config loads and validates the server settings.
+//
+// Settings come from built-in defaults, then from an optional JSON file named
+// by CONFIG_FILE, then from environment variables, each overriding the last.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ModeDebug   = "debug"
+	ModeRelease = "release"
+	ModeTest    = "test"
+)
+
+// developmentDSN and developmentSecret only work on a developer machine and
+// are refused in release mode.
+const (
+	developmentDSN    = "host=localhost user=postgres password=63946229 dbname=cafe port=5432 sslmode=disable"
+	developmentSecret = "enweyos"
+)
+
+type Config struct {
+	Mode            string
+	Port            int
+	DatabaseDSN     string
+	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	AllowedOrigins  []string
+	UploadDir       string
+	// MaxUploadMB limits the size of a single uploaded image.
+	MaxUploadMB int64
+	// MaxMultipartMB is how much of a multipart form is kept in memory;
+	// larger bodies are buffered in temporary files.
+	MaxMultipartMB         int64
+	PriceSchedulerInterval time.Duration
+	ReadHeaderTimeout      time.Duration
+	ReadTimeout            time.Duration
+	WriteTimeout           time.Duration
+	IdleTimeout            time.Duration
+	MaxHeaderKB            int
+	// ShutdownTimeout bounds how long in-flight requests may take to finish
+	// after SIGTERM before the server closes their connections.
+	ShutdownTimeout time.Duration
+	LogLevel        string
+	LogFormat       string
+	// SlowQueryThreshold is how long a statement may take before it is
+	// logged as slow.
+	SlowQueryThreshold time.Duration
+	// MenuMaxAge is how long guests' browsers and proxies may show a public
+	// menu before asking whether it changed.
+	MenuMaxAge time.Duration
+}
+
+// Current is the configuration the server runs with. It holds the defaults
+// until Load replaces it.
+var Current = Default()
+
+func Default() Config {
+	return Config{
+		Mode:                   ModeDebug,
+		Port:                   8083,
+		DatabaseDSN:            developmentDSN,
+		JWTSecret:              developmentSecret,
+		AccessTokenTTL:         15 * time.Minute,
+		RefreshTokenTTL:        12 * time.Hour,
+		AllowedOrigins:         []string{"http://localhost:3000"},
+		UploadDir:              "./uploads",
+		MaxUploadMB:            5,
+		MaxMultipartMB:         8,
+		PriceSchedulerInterval: time.Minute,
+		ReadHeaderTimeout:      10 * time.Second,
+		ReadTimeout:            time.Minute,
+		WriteTimeout:           time.Minute,
+		IdleTimeout:            2 * time.Minute,
+		MaxHeaderKB:            64,
+		ShutdownTimeout:        30 * time.Second,
+		LogLevel:               "info",
+		LogFormat:              "json",
+		SlowQueryThreshold:     200 * time.Millisecond,
+		MenuMaxAge:             time.Minute,
+	}
+}
+
+// MaxUploadBytes is MaxUploadMB in bytes.
+func (c Config) MaxUploadBytes() int64 {
+	return c.MaxUploadMB << 20
+}
+
+// MaxMultipartBytes is MaxMultipartMB in bytes.
+func (c Config) MaxMultipartBytes() int64 {
+	return c.MaxMultipartMB << 20
+}
+
+func (c Config) Release() bool {
+	return c.Mode == ModeRelease
+}
+
+// setting maps a key of the config file to its environment variable.
+type setting struct {
+	key, env string
+	apply    func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{"mode", "GIN_MODE", func(c *Config, v string) error {
+		c.Mode = v
+		return nil
+	}},
+	{"port", "PORT", func(c *Config, v string) (err error) {
+		c.Port, err = strconv.Atoi(v)
+		return err
+	}},
+	{"database_dsn", "DATABASE_DSN", func(c *Config, v string) error {
+		c.DatabaseDSN = v
+		return nil
+	}},
+	// enweyos is the variable older deployments set the secret in.
+	{"", "enweyos", func(c *Config, v string) error {
+		c.JWTSecret = v
+		return nil
+	}},
+	{"jwt_secret", "JWT_SECRET", func(c *Config, v string) error {
+		c.JWTSecret = v
+		return nil
+	}},
+	{"access_token_ttl", "ACCESS_TOKEN_TTL", func(c *Config, v string) (err error) {
+		c.AccessTokenTTL, err = time.ParseDuration(v)
+		return err
+	}},
+	{"refresh_token_ttl", "REFRESH_TOKEN_TTL", func(c *Config, v string) (err error) {
+		c.RefreshTokenTTL, err = time.ParseDuration(v)
+		return err
+	}},
+	{"allowed_origins", "ALLOWED_ORIGINS", func(c *Config, v string) error {
+		c.AllowedOrigins = splitList(v)
+		return nil
+	}},
+	{"upload_dir", "UPLOAD_DIR", func(c *Config, v string) error {
+		c.UploadDir = v
+		return nil
+	}},
+	{"max_upload_mb", "MAX_UPLOAD_MB", func(c *Config, v string) (err error) {
+		c.MaxUploadMB, err = strconv.ParseInt(v, 10, 64)
+		return err
+	}},
+	{"max_multipart_mb", "MAX_MULTIPART_MB", func(c *Config, v string) (err error) {
+		c.MaxMultipartMB, err = strconv.ParseInt(v, 10, 64)
+		return err
+	}},
+	{"price_scheduler_interval", "PRICE_SCHEDULER_INTERVAL", func(c *Config, v string) (err error) {
+		c.PriceSchedulerInterval, err = time.ParseDuration(v)
+		return err
+	}},
+	{"read_header_timeout", "READ_HEADER_TIMEOUT", func(c *Config, v string) (err error) {
+		c.ReadHeaderTimeout, err = time.ParseDuration(v)
+		return err
+	}},
+	{"read_timeout", "READ_TIMEOUT", func(c *Config, v string) (err error) {
+		c.ReadTimeout, err = time.ParseDuration(v)
+		return err
+	}},
+	{"write_timeout", "WRITE_TIMEOUT", func(c *Config, v string) (err error) {
+		c.WriteTimeout, err = time.ParseDuration(v)
+		return err
+	}},
+	{"idle_timeout", "IDLE_TIMEOUT", func(c *Config, v string) (err error) {
+		c.IdleTimeout, err = time.ParseDuration(v)
+		return err
+	}},
+	{"max_header_kb", "MAX_HEADER_KB", func(c *Config, v string) (err error) {
+		c.MaxHeaderKB, err = strconv.Atoi(v)
+		return err
+	}},
+	{"shutdown_timeout", "SHUTDOWN_TIMEOUT", func(c *Config, v string) (err error) {
+		c.ShutdownTimeout, err = time.ParseDuration(v)
+		return err
+	}},
+	{"log_level", "LOG_LEVEL", func(c *Config, v string) error {
+		c.LogLevel = strings.ToLower(v)
+		return nil
+	}},
+	{"log_format", "LOG_FORMAT", func(c *Config, v string) error {
+		c.LogFormat = strings.ToLower(v)
+		return nil
+	}},
+	{"slow_query_threshold", "SLOW_QUERY_THRESHOLD", func(c *Config, v string) (err error) {
+		c.SlowQueryThreshold, err = time.ParseDuration(v)
+		return err
+	}},
+	{"menu_max_age", "MENU_MAX_AGE", func(c *Config, v string) (err error) {
+		c.MenuMaxAge, err = time.ParseDuration(v)
+		return err
+	}},
+}
+
+// Load reads the optional file named by CONFIG_FILE and the environment on
+// top of the defaults and validates the result.
+func Load() (Config, error) {
+	cfg := Default()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return cfg, err
+		}
+		for _, s := range settings {
+			if value, ok := values[s.key]; ok && s.key != "" {
+				if err := s.apply(&cfg, value); err != nil {
+					return cfg, fmt.Errorf("%s: invalid %s: %w", path, s.key, err)
+				}
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok && value != "" {
+			if err := s.apply(&cfg, value); err != nil {
+				return cfg, fmt.Errorf("invalid %s: %w", s.env, err)
+			}
+		}
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// readFile reads a flat JSON object. Lists may be given as arrays or as
+// comma-separated strings.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	known := map[string]bool{}
+	for _, s := range settings {
+		known[s.key] = true
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if !known[key] || key == "" {
+			return nil, fmt.Errorf("%s: unknown setting %q", path, key)
+		}
+		switch v := value.(type) {
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+// Validate checks every setting and, in release mode, refuses the
+// development defaults.
+func (c Config) Validate() error {
+	var problems []string
+	switch c.Mode {
+	case ModeDebug, ModeRelease, ModeTest:
+	default:
+		problems = append(problems, fmt.Sprintf("GIN_MODE must be %s, %s or %s", ModeDebug, ModeRelease, ModeTest))
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		problems = append(problems, "PORT must be between 1 and 65535")
+	}
+	if c.DatabaseDSN == "" {
+		problems = append(problems, "DATABASE_DSN must not be empty")
+	}
+	if c.JWTSecret == "" {
+		problems = append(problems, "JWT_SECRET must not be empty")
+	}
+	if c.AccessTokenTTL <= 0 || c.RefreshTokenTTL <= 0 {
+		problems = append(problems, "token TTLs must be positive")
+	} else if c.RefreshTokenTTL <= c.AccessTokenTTL {
+		problems = append(problems, "REFRESH_TOKEN_TTL must be longer than ACCESS_TOKEN_TTL")
+	}
+	if c.UploadDir == "" {
+		problems = append(problems, "UPLOAD_DIR must not be empty")
+	}
+	if c.MaxUploadMB < 1 {
+		problems = append(problems, "MAX_UPLOAD_MB must be at least 1")
+	}
+	if c.MaxMultipartMB < 1 {
+		problems = append(problems, "MAX_MULTIPART_MB must be at least 1")
+	}
+	if c.PriceSchedulerInterval < time.Second {
+		problems = append(problems, "PRICE_SCHEDULER_INTERVAL must be at least 1s")
+	}
+	if c.ReadHeaderTimeout <= 0 || c.ReadTimeout <= 0 || c.WriteTimeout <= 0 || c.IdleTimeout <= 0 || c.ShutdownTimeout <= 0 {
+		problems = append(problems, "server timeouts must be positive")
+	} else if c.ReadHeaderTimeout > c.ReadTimeout {
+		problems = append(problems, "READ_HEADER_TIMEOUT must not be longer than READ_TIMEOUT")
+	}
+	if c.MaxHeaderKB < 1 {
+		problems = append(problems, "MAX_HEADER_KB must be at least 1")
+	}
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, "LOG_LEVEL must be debug, info, warn or error")
+	}
+	if c.LogFormat != "json" && c.LogFormat != "text" {
+		problems = append(problems, "LOG_FORMAT must be json or text")
+	}
+	if c.SlowQueryThreshold < 0 {
+		problems = append(problems, "SLOW_QUERY_THRESHOLD must not be negative")
+	}
+	if c.MenuMaxAge < 0 {
+		problems = append(problems, "MENU_MAX_AGE must not be negative")
+	}
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			problems = append(problems, "ALLOWED_ORIGINS cannot contain * because credentials are allowed")
+		} else if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			problems = append(problems, fmt.Sprintf("ALLOWED_ORIGINS entry %q must start with http:// or https://", origin))
+		}
+	}
+
+	if c.Release() {
+		if c.DatabaseDSN == developmentDSN {
+			problems = append(problems, "DATABASE_DSN must be set in release mode")
+		}
+		if c.JWTSecret == developmentSecret || len(c.JWTSecret) < 32 {
+			problems = append(problems, "JWT_SECRET must be set to at least 32 characters in release mode")
+		}
+		if len(c.AllowedOrigins) == 0 {
+			problems = append(problems, "ALLOWED_ORIGINS must be set in release mode")
+		}
+		for _, origin := range c.AllowedOrigins {
+			if strings.Contains(origin, "://localhost") || strings.Contains(origin, "://127.0.0.1") {
+				problems = append(problems, fmt.Sprintf("ALLOWED_ORIGINS entry %q is not allowed in release mode", origin))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration:\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
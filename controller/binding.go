@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"cafe/apierr"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"io"
+	"reflect"
+	"sort"
+)
+
+const mimeMergePatch = "application/merge-patch+json"
+
+// nulls records the fields that a JSON body sets to null. Embedded in an
+// input, it lets partial updates clear fields the way JSON Merge Patch
+// (RFC 7396) does. Forms cannot send null, so they never clear anything.
+type nulls struct {
+	fields map[string]bool
+}
+
+func (n *nulls) setNulls(fields map[string]bool) {
+	n.fields = fields
+}
+
+func (n nulls) null(field string) bool {
+	return n.fields[field]
+}
+
+// required rejects clearing any of fields.
+func (n nulls) required(fields ...string) error {
+	for _, field := range fields {
+		if n.fields[field] {
+			return apierr.Field(field, apierr.CodeRequired)
+		}
+	}
+	return nil
+}
+
+// bindBody fills obj from a JSON body or from a urlencoded or multipart form,
+// using the json and form tags, and then checks its binding tags. Empty form
+// values count as not sent, so pointer fields stay nil for fields a client
+// leaves blank. Values that cannot be parsed are reported under their field.
+func bindBody(c *gin.Context, obj any) error {
+	if contentType := c.ContentType(); contentType == binding.MIMEJSON || contentType == mimeMergePatch {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return apierr.BadRequest(apierr.CodeInvalidBody)
+		}
+		if err := json.Unmarshal(body, obj); err != nil {
+			return jsonFieldError(body, obj, err)
+		}
+		if patch, ok := obj.(interface{ setNulls(map[string]bool) }); ok {
+			patch.setNulls(nullFields(body))
+		}
+	} else {
+		form, err := postForm(c)
+		if err != nil {
+			return apierr.BadRequest(apierr.CodeInvalidBody)
+		}
+		if err := binding.MapFormWithTag(obj, form, "form"); err != nil {
+			return formFieldError(form, obj)
+		}
+	}
+
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return apierr.Bind(err)
+	}
+	return nil
+}
+
+// postForm returns the non-empty values of the request's form.
+func postForm(c *gin.Context) (map[string][]string, error) {
+	if c.ContentType() == binding.MIMEMultipartPOSTForm {
+		if _, err := c.MultipartForm(); err != nil {
+			return nil, err
+		}
+	} else if err := c.Request.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	form := map[string][]string{}
+	for key, values := range c.Request.PostForm {
+		for _, value := range values {
+			if value != "" {
+				form[key] = append(form[key], value)
+			}
+		}
+	}
+	return form, nil
+}
+
+// nullFields returns the top-level fields of a JSON object that are null.
+func nullFields(body []byte) map[string]bool {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return nil
+	}
+	null := map[string]bool{}
+	for key, value := range fields {
+		if string(value) == "null" {
+			null[key] = true
+		}
+	}
+	return null
+}
+
+// formFieldError finds the form field that cannot be mapped onto obj by
+// mapping the fields one at a time.
+func formFieldError(form map[string][]string, obj any) error {
+	for _, key := range sortedKeys(form) {
+		err := binding.MapFormWithTag(newLike(obj), map[string][]string{key: form[key]}, "form")
+		if err != nil {
+			return valueError(key, err)
+		}
+	}
+	return apierr.BadRequest(apierr.CodeInvalidBody)
+}
+
+// jsonFieldError names the field of a JSON body that cannot be decoded into
+// obj, or reports the body as malformed.
+func jsonFieldError(body []byte, obj any, err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return apierr.Field(typeErr.Field, apierr.CodeInvalidValue)
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) == nil {
+		for _, key := range sortedKeys(fields) {
+			single, _ := json.Marshal(map[string]json.RawMessage{key: fields[key]})
+			if err := json.Unmarshal(single, newLike(obj)); err != nil {
+				return valueError(key, err)
+			}
+		}
+	}
+	return apierr.BadRequest(apierr.CodeInvalidBody)
+}
+
+// valueError reports a value of field that could not be parsed, keeping the
+// code of validation errors such as those of model.Money.
+func valueError(field string, err error) error {
+	var fieldErr apierr.FieldError
+	if errors.As(err, &fieldErr) {
+		return apierr.Field(field, apierr.Code(fieldErr.ErrorCode()), fieldErr.ErrorArgs()...)
+	}
+	return apierr.Field(field, apierr.CodeInvalidValue)
+}
+
+// newLike returns a pointer to a new zero value of the type obj points to.
+func newLike(obj any) any {
+	return reflect.New(reflect.TypeOf(obj).Elem()).Interface()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
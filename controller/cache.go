@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"cafe/config"
+	"cafe/model"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Public menus are cached by guests' browsers and proxies for
+// config.Current.MenuMaxAge and then revalidated against the time the menu of
+// the cafe last changed, which is the UpdatedAt of the cafe: TouchMenu moves
+// it on every change a manager makes.
+
+// menuCache holds the validators of a public menu response. The zero value,
+// for a cafe that does not exist, sets no headers and is never fresh.
+type menuCache struct {
+	modified time.Time
+}
+
+// loadMenuCache finds when the menu of a cafe last changed. cafeID is the ID
+// of the cafe or a query selecting it; gorm.ErrRecordNotFound means there is
+// no such cafe.
+//
+// Schedules, promotions and foods coming back from the stop-list change a
+// live menu as time passes, without any write, so while a cafe has any of
+// them its menu counts as changed at the start of every minute.
+func (ctrl *Controller) loadMenuCache(c *gin.Context, cafeID any) (menuCache, error) {
+	now := time.Now()
+	var state struct {
+		UpdatedAt time.Time
+		Timed     bool
+	}
+	err := ctrl.db(c).Model(&model.Cafe{}).
+		Select(`updated_at,
+			EXISTS (SELECT 1 FROM menu_schedules WHERE cafe_id = caves.id AND deleted_at IS NULL) OR
+			EXISTS (SELECT 1 FROM promotions WHERE cafe_id = caves.id AND is_active AND deleted_at IS NULL) OR
+			EXISTS (SELECT 1 FROM foods WHERE cafe_id = caves.id AND available_again_at > ? AND deleted_at IS NULL) AS timed`, now).
+		Where("id = (?)", cafeID).
+		Take(&state).Error
+	if err != nil {
+		return menuCache{}, err
+	}
+
+	cache := menuCache{modified: state.UpdatedAt}
+	if minute := now.Truncate(time.Minute); state.Timed && c.Query("at") == "" && minute.After(cache.modified) {
+		cache.modified = minute
+	}
+	return cache, nil
+}
+
+// etag is weak: the same menu may be encoded differently.
+func (m menuCache) etag() string {
+	return fmt.Sprintf(`W/"%x"`, m.modified.UnixMicro())
+}
+
+func (m menuCache) setHeaders(c *gin.Context) {
+	if m.modified.IsZero() {
+		return
+	}
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(config.Current.MenuMaxAge.Seconds())))
+	c.Header("ETag", m.etag())
+	c.Header("Last-Modified", m.modified.UTC().Format(http.TimeFormat))
+}
+
+// notModified answers the request with 304 Not Modified when the client's
+// copy of the menu is still current. If-None-Match takes precedence over
+// If-Modified-Since.
+func (m menuCache) notModified(c *gin.Context) bool {
+	if m.modified.IsZero() {
+		return false
+	}
+	fresh := false
+	if header := c.GetHeader("If-None-Match"); header != "" {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(m.etag(), "W/") {
+				fresh = true
+			}
+		}
+	} else if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil {
+		fresh = !m.modified.Truncate(time.Second).After(since)
+	}
+	if !fresh {
+		return false
+	}
+	m.setHeaders(c)
+	c.AbortWithStatus(http.StatusNotModified)
+	return true
+}
+
+// TouchMenu marks the menu of the manager's cafe as changed after every
+// successful write, so that cached public menus are fetched again.
+func (ctrl *Controller) TouchMenu(c *gin.Context) {
+	c.Next()
+
+	if c.Request.Method == http.MethodGet || c.Writer.Status() >= http.StatusMultipleChoices {
+		return
+	}
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return
+	}
+	if err := model.TouchCafe(ctrl.db(c), userID.(uint)); err != nil {
+		slog.WarnContext(c.Request.Context(), "Failed to mark the menu as changed", "cafe_id", userID, "error", err)
+	}
+}
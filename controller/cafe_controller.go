@@ -1,7 +1,8 @@
 package controller
 
 import (
-	"cafe/database"
+	"cafe/apierr"
+	"cafe/metrics"
 	"cafe/model"
 	"cafe/utils"
 	"errors"
@@ -10,15 +11,11 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 )
 
-const uploadDir = "./uploads"
-
-func LoginManager(c *gin.Context) {
+func (ctrl *Controller) LoginManager(c *gin.Context) {
 	type Request struct {
 		Login    string `form:"login" binding:"required"`
 		Password string `form:"password" binding:"required"`
@@ -26,51 +23,65 @@ func LoginManager(c *gin.Context) {
 
 	var req Request
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Login and password are required"})
+		apierr.Respond(c, apierr.Bind(err))
 		return
 	}
 
 	var user model.Cafe
-	if err := database.DB.Where("login = ?", req.Login).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid login credentials"})
+	if err := ctrl.db(c).Where("login = ?", req.Login).First(&user).Error; err != nil {
+		metrics.CountLogin(metrics.LoginFailure)
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeInvalidCredentials))
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid login credentials"})
+		metrics.CountLogin(metrics.LoginFailure)
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeInvalidCredentials))
 		return
 	}
 
 	access, refresh, err := utils.GenerateTokens(user.UserRole, user.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to generate tokens: %w", err)))
 		return
 	}
 
+	metrics.CountLogin(metrics.LoginSuccess)
 	c.JSON(http.StatusOK, gin.H{
 		"access_token":  access,
 		"refresh_token": refresh,
 	})
 }
 
-func UpdateMyCafe(c *gin.Context) {
+// cafeInput is the body of UpdateMyCafe, sent as JSON or as a form. Fields
+// that are left out keep their current values.
+type cafeInput struct {
+	Name                 *string  `json:"name" form:"name"`
+	Password             *string  `json:"password" form:"password"`
+	HideUnavailableFoods *bool    `json:"hide_unavailable_foods" form:"hide_unavailable_foods"`
+	Timezone             *string  `json:"timezone" form:"timezone"`
+	Currency             *string  `json:"currency" form:"currency"`
+	PhoneNumbers         []string `json:"phone_numbers" form:"phone_numbers"`
+}
+
+func (ctrl *Controller) UpdateMyCafe(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "Unauthorized access",
-		})
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	var in cafeInput
+	if err := bindBody(c, &in); err != nil {
+		apierr.Respond(c, err)
 		return
 	}
 
-	tx := database.DB.Begin()
+	tx := ctrl.db(c).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Unexpected error occurred",
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("panic: %v", r)))
 		}
 	}()
 
@@ -78,57 +89,72 @@ func UpdateMyCafe(c *gin.Context) {
 	if err := tx.Where("id = ?", userID.(uint)).First(&cafe).Error; err != nil {
 		tx.Rollback()
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"error":   "Cafe not found for this user",
-			})
+			apierr.Respond(c, apierr.NotFound(apierr.CodeCafeNotFound))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Failed to fetch cafe: " + err.Error(),
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch cafe: %w", err)))
 		}
 		return
 	}
+	if err := checkIfMatch(c, cafe.Version); err != nil {
+		tx.Rollback()
+		apierr.Respond(c, err)
+		return
+	}
+
+	if in.HideUnavailableFoods != nil {
+		cafe.HideUnavailableFoods = *in.HideUnavailableFoods
+	}
 
+	if in.Timezone != nil && *in.Timezone != "" {
+		if _, err := time.LoadLocation(*in.Timezone); err != nil {
+			tx.Rollback()
+			apierr.Respond(c, apierr.Field("timezone", apierr.CodeInvalidTimezone))
+			return
+		}
+		cafe.Timezone = *in.Timezone
+	}
+
+	if in.Currency != nil && *in.Currency != "" {
+		if !model.IsSupportedCurrency(*in.Currency) {
+			tx.Rollback()
+			apierr.Respond(c, apierr.Field("currency", apierr.CodeInvalidCurrency, strings.Join(model.SupportedCurrencies, ", ")))
+			return
+		}
+		cafe.Currency = *in.Currency
+	}
+
+	oldLogo := cafe.Logo
 	if err := processLogoUpload(c, &cafe); err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Logo upload failed: " + err.Error(),
-		})
+		apierr.Respond(c, apierr.Wrap(err, "Logo upload failed"))
 		return
 	}
 
-	if name := c.PostForm("name"); name != "" {
-		cafe.Name = name
+	if in.Name != nil && *in.Name != "" {
+		cafe.Name = *in.Name
 	}
 
-	if newPassword := c.PostForm("password"); newPassword != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if in.Password != nil && *in.Password != "" {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*in.Password), bcrypt.DefaultCost)
 		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Failed to hash password: " + err.Error(),
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to hash password: %w", err)))
 			return
 		}
 		cafe.Password = string(hashedPassword)
 	}
 
-	if phoneNumbers := c.PostFormArray("phone_numbers"); len(phoneNumbers) > 0 {
+	// A JSON body clears the phone numbers with an empty list; a form cannot,
+	// since empty form values count as not sent.
+	if in.PhoneNumbers != nil {
 		if err := tx.Where("cafe_id = ?", cafe.ID).Delete(&model.CafePhone{}).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Failed to clear existing phone numbers: " + err.Error(),
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to clear existing phone numbers: %w", err)))
 			return
 		}
 
-		var newPhones []model.CafePhone
-		for _, phone := range phoneNumbers {
+		newPhones := []model.CafePhone{}
+		for _, phone := range in.PhoneNumbers {
 			if phone == "" {
 				continue
 			}
@@ -141,32 +167,29 @@ func UpdateMyCafe(c *gin.Context) {
 		if len(newPhones) > 0 {
 			if err := tx.Create(&newPhones).Error; err != nil {
 				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"error":   "Failed to save new phone numbers: " + err.Error(),
-				})
+				apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to save new phone numbers: %w", err)))
 				return
 			}
 		}
 		cafe.PhoneNumbers = newPhones
 	}
 
-	if err := tx.Save(&cafe).Error; err != nil {
+	if err := saveVersion(tx, &cafe, &cafe.Version); err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Failed to update cafe: " + err.Error(),
-		})
+		if cafe.Logo != oldLogo {
+			ctrl.removeImage(c, cafe.Logo)
+		}
+		apierr.Respond(c, apierr.Wrap(err, "Failed to update cafe"))
 		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Transaction failed: " + err.Error(),
-		})
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Transaction failed: %w", err)))
 		return
 	}
+	if cafe.Logo != oldLogo {
+		ctrl.removeImage(c, oldLogo)
+	}
 
 	phoneNumbers := make([]string, len(cafe.PhoneNumbers))
 	for i, pn := range cafe.PhoneNumbers {
@@ -174,12 +197,17 @@ func UpdateMyCafe(c *gin.Context) {
 	}
 
 	response := gin.H{
-		"id":            cafe.ID,
-		"name":          cafe.Name,
-		"logo":          cafe.Logo,
-		"phone_numbers": phoneNumbers,
+		"id":                     cafe.ID,
+		"name":                   cafe.Name,
+		"logo":                   cafe.Logo,
+		"phone_numbers":          phoneNumbers,
+		"hide_unavailable_foods": cafe.HideUnavailableFoods,
+		"timezone":               cafe.Timezone,
+		"currency":               cafe.Currency,
+		"version":                cafe.Version,
 	}
 
+	setETag(c, cafe.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Cafe updated successfully",
@@ -195,93 +223,63 @@ func processLogoUpload(c *gin.Context, cafe *model.Cafe) error {
 		}
 		return fmt.Errorf("failed to get uploaded file: %v", err)
 	}
+	metrics.ObserveUpload(metrics.UploadLogo, file.Size)
 
-	if file.Size > 5<<20 {
-		return fmt.Errorf("file too large (max 5MB)")
-	}
-
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	allowedExts := map[string]bool{
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-	}
-	if !allowedExts[ext] {
-		return fmt.Errorf("invalid file type, only JPG/JPEG/PNG allowed")
-	}
-
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		return fmt.Errorf("failed to create upload directory: %v", err)
-	}
-
-	newFileName := fmt.Sprintf("cafe-%d-%d%s", cafe.ID, time.Now().UnixNano(), ext)
-	filePath := filepath.Join(uploadDir, newFileName)
-
-	if cafe.Logo != "" {
-		if err := os.Remove(filepath.Join(uploadDir, cafe.Logo)); err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("failed to delete old logo: %v", err)
-		}
-	}
-
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
-		return fmt.Errorf("failed to save file: %v", err)
+	logo, err := saveImage(c, file, "logo", "cafe", cafe.ID)
+	if err != nil {
+		return err
 	}
-
-	cafe.Logo = newFileName
+	cafe.Logo = logo
 	return nil
 }
 
-func GetMyCafe(c *gin.Context) {
+func (ctrl *Controller) GetMyCafe(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "Unauthorized access",
-		})
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
 		return
 	}
 
 	var cafe model.Cafe
-	result := database.DB.Preload("PhoneNumbers").First(&cafe, userID.(uint))
+	result := ctrl.db(c).Preload("PhoneNumbers").First(&cafe, userID.(uint))
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"error":   "Cafe not found",
-			})
+			apierr.Respond(c, apierr.NotFound(apierr.CodeCafeNotFound))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Failed to fetch cafe",
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch cafe: %w", result.Error)))
 		}
 		return
 	}
 
+	setETag(c, cafe.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"id":            cafe.ID,
-			"name":          cafe.Name,
-			"user_role":     cafe.UserRole,
-			"logo":          cafe.Logo,
-			"code":          cafe.Code,
-			"expiry_date":   cafe.ExpiryDate,
-			"phone_numbers": cafe.PhoneNumbers,
+			"id":                     cafe.ID,
+			"name":                   cafe.Name,
+			"user_role":              cafe.UserRole,
+			"logo":                   cafe.Logo,
+			"code":                   cafe.Code,
+			"expiry_date":            cafe.ExpiryDate,
+			"phone_numbers":          cafe.PhoneNumbers,
+			"hide_unavailable_foods": cafe.HideUnavailableFoods,
+			"timezone":               cafe.Timezone,
+			"currency":               cafe.Currency,
+			"version":                cafe.Version,
 		},
 	})
 }
 
-func RefreshTokenFunc(c *gin.Context) {
+func (ctrl *Controller) RefreshTokenFunc(c *gin.Context) {
 	oldRefreshToken := c.PostForm("refresh_token")
 	if oldRefreshToken == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token is required"})
+		apierr.Respond(c, apierr.Field("refresh_token", apierr.CodeRequired))
 		return
 	}
 
 	newAccessToken, newRefreshToken, err := utils.RefreshTokens(oldRefreshToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeInvalidToken))
 		return
 	}
 
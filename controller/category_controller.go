@@ -1,118 +1,144 @@
 package controller
 
 import (
-	"cafe/database"
+	"cafe/apierr"
+	"cafe/metrics"
 	"cafe/model"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
-	"strings"
-	"time"
 )
 
-func AddCategory(c *gin.Context) {
+// categoryInput is the body of AddCategory and UpdateCategory, sent as JSON
+// or as a form. Fields that are left out keep their current values; a JSON
+// null clears a name or moves the category to the top level.
+type categoryInput struct {
+	nulls
+	NameTM *string `json:"name_tm" form:"name_tm"`
+	NameRU *string `json:"name_ru" form:"name_ru"`
+	NameEN *string `json:"name_en" form:"name_en"`
+	// ParentID 0 moves the category to the top level too.
+	ParentID  *uint `json:"parent_id" form:"parent_id"`
+	SortOrder *int  `json:"sort_order" form:"sort_order"`
+}
+
+func (in categoryInput) apply(category *model.FoodCategory) error {
+	if err := in.required("sort_order"); err != nil {
+		return err
+	}
+	for _, field := range []struct {
+		name   string
+		value  *string
+		target *string
+	}{
+		{"name_tm", in.NameTM, &category.NameTM},
+		{"name_ru", in.NameRU, &category.NameRU},
+		{"name_en", in.NameEN, &category.NameEN},
+	} {
+		if field.value != nil {
+			*field.target = *field.value
+		} else if in.null(field.name) {
+			*field.target = ""
+		}
+	}
+
+	if category.NameTM == "" && category.NameRU == "" && category.NameEN == "" {
+		return apierr.Field("name", apierr.CodeNameRequired)
+	}
+	return nil
+}
+
+// parentID returns the requested parent, or nil for the top level.
+func (in categoryInput) parentID() *uint {
+	if in.ParentID == nil || *in.ParentID == 0 {
+		return nil
+	}
+	return in.ParentID
+}
+
+func (ctrl *Controller) AddCategory(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "User ID not found in context",
-		})
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	var in categoryInput
+	if err := bindBody(c, &in); err != nil {
+		apierr.Respond(c, err)
 		return
 	}
 
 	var category model.FoodCategory
 	category.CafeId = userID.(uint)
-	category.NameTM = c.PostForm("name_tm")
-	category.NameRU = c.PostForm("name_ru")
-	category.NameEN = c.PostForm("name_en")
-
-	if category.NameTM == "" && category.NameRU == "" && category.NameEN == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "At least one category name (TM, RU, or EN) is required",
-		})
+	if err := in.apply(&category); err != nil {
+		apierr.Respond(c, err)
 		return
 	}
 
-	tx := database.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Unexpected error occurred",
-			})
+	if in.SortOrder != nil {
+		category.SortOrder = *in.SortOrder
+	} else {
+		nextOrder, err := nextSortOrder(ctrl.db(c).Model(&model.FoodCategory{}).Where("cafe_id = ?", category.CafeId))
+		if err != nil {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to determine sort order: %w", err)))
+			return
 		}
-	}()
+		category.SortOrder = nextOrder
+	}
 
-	file, err := c.FormFile("image")
-	if err == nil {
-		if file.Size > 5<<20 {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Image size exceeds 5MB limit",
-			})
+	if parentID := in.parentID(); parentID != nil {
+		tree, err := loadCategoryTree(ctrl.db(c), category.CafeId)
+		if err != nil {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to load categories: %w", err)))
 			return
 		}
-
-		ext := strings.ToLower(filepath.Ext(file.Filename))
-		allowedExts := map[string]bool{".jpg": true, ".jpeg": true, ".png": true}
-		if !allowedExts[ext] {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Invalid file type, only JPG/JPEG/PNG allowed",
-			})
+		if err := tree.checkParent(0, *parentID); err != nil {
+			apierr.Respond(c, err)
 			return
 		}
+		category.ParentID = parentID
+	}
 
-		if err := os.MkdirAll(uploadDir, 0755); err != nil {
+	tx := ctrl.db(c).Begin()
+	defer func() {
+		if r := recover(); r != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to create upload directory: %v", err),
-			})
-			return
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("panic: %v", r)))
 		}
+	}()
 
-		newFileName := fmt.Sprintf("category-%d-%d%s", category.CafeId, time.Now().UnixNano(), ext)
-		filePath := filepath.Join(uploadDir, newFileName)
-
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
+	if file, err := c.FormFile("image"); err == nil {
+		metrics.ObserveUpload(metrics.UploadCategoryImage, file.Size)
+		image, err := saveImage(c, file, "image", "category", category.CafeId)
+		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to save image: %v", err),
-			})
+			apierr.Respond(c, apierr.Wrap(err, "Image upload failed"))
 			return
 		}
-
-		category.Image = newFileName
+		category.Image = image
 	}
 
 	if err := tx.Create(&category).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Failed to create category: %v", err),
-		})
+		if isForeignKeyViolation(err) {
+			apierr.Respond(c, apierr.Conflict(apierr.CodeParentCategoryGone))
+			return
+		}
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to create category: %w", err)))
 		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Transaction failed: %v", err),
-		})
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Transaction failed: %w", err)))
 		return
 	}
 
+	setETag(c, category.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Category added successfully",
@@ -120,25 +146,25 @@ func AddCategory(c *gin.Context) {
 	})
 }
 
-func UpdateCategory(c *gin.Context) {
+func (ctrl *Controller) UpdateCategory(c *gin.Context) {
 	id := c.Param("id")
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "User ID not found in context",
-		})
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
 		return
 	}
 
-	tx := database.DB.Begin()
+	var in categoryInput
+	if err := bindBody(c, &in); err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	tx := ctrl.db(c).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Unexpected error occurred",
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("panic: %v", r)))
 		}
 	}()
 
@@ -146,196 +172,208 @@ func UpdateCategory(c *gin.Context) {
 	if err := tx.First(&category, id).Error; err != nil {
 		tx.Rollback()
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"error":   "Category not found",
-			})
+			apierr.Respond(c, apierr.NotFound(apierr.CodeCategoryNotFound))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to fetch category: %v", err),
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch category: %w", err)))
 		}
 		return
 	}
 
 	if category.CafeId != userID.(uint) {
 		tx.Rollback()
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"error":   "You don't have permission to update this category",
-		})
+		apierr.Respond(c, apierr.Forbidden(apierr.CodeCategoryForbidden))
+		return
+	}
+	if err := checkIfMatch(c, category.Version); err != nil {
+		tx.Rollback()
+		apierr.Respond(c, err)
 		return
 	}
 
-	if nameTM := c.PostForm("name_tm"); nameTM != "" {
-		category.NameTM = nameTM
+	if err := in.apply(&category); err != nil {
+		tx.Rollback()
+		apierr.Respond(c, err)
+		return
 	}
-	if nameRU := c.PostForm("name_ru"); nameRU != "" {
-		category.NameRU = nameRU
+	if in.SortOrder != nil {
+		category.SortOrder = *in.SortOrder
 	}
-	if nameEN := c.PostForm("name_en"); nameEN != "" {
-		category.NameEN = nameEN
+	if in.ParentID != nil || in.null("parent_id") {
+		parentID := in.parentID()
+		if parentID != nil {
+			tree, err := loadCategoryTree(tx, category.CafeId)
+			if err != nil {
+				tx.Rollback()
+				apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to load categories: %w", err)))
+				return
+			}
+			if err := tree.checkParent(category.ID, *parentID); err != nil {
+				tx.Rollback()
+				apierr.Respond(c, err)
+				return
+			}
+		}
+		category.ParentID = parentID
 	}
 
-	file, err := c.FormFile("image")
-	if err == nil {
-		if file.Size > 5<<20 {
+	oldImage := category.Image
+	if file, err := c.FormFile("image"); err == nil {
+		metrics.ObserveUpload(metrics.UploadCategoryImage, file.Size)
+		image, err := saveImage(c, file, "image", "category", category.CafeId)
+		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Image size exceeds 5MB limit",
-			})
+			apierr.Respond(c, apierr.Wrap(err, "Image upload failed"))
 			return
 		}
+		category.Image = image
+	}
 
-		ext := strings.ToLower(filepath.Ext(file.Filename))
-		allowedExts := map[string]bool{".jpg": true, ".jpeg": true, ".png": true}
-		if !allowedExts[ext] {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Invalid file type, only JPG/JPEG/PNG allowed",
-			})
-			return
+	if err := saveVersion(tx, &category, &category.Version); err != nil {
+		tx.Rollback()
+		if category.Image != oldImage {
+			ctrl.removeImage(c, category.Image)
 		}
-
-		if err := os.MkdirAll(uploadDir, 0755); err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to create upload directory: %v", err),
-			})
+		if isForeignKeyViolation(err) {
+			apierr.Respond(c, apierr.Conflict(apierr.CodeParentCategoryGone))
 			return
 		}
+		apierr.Respond(c, apierr.Wrap(err, "Failed to update category"))
+		return
+	}
 
-		if category.Image != "" {
-			if err := os.Remove(filepath.Join(uploadDir, category.Image)); err != nil && !os.IsNotExist(err) {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"error":   fmt.Sprintf("Failed to delete old image: %v", err),
-				})
-				return
-			}
-		}
-
-		newFileName := fmt.Sprintf("category-%d-%d%s", category.CafeId, time.Now().UnixNano(), ext)
-		filePath := filepath.Join(uploadDir, newFileName)
+	if err := tx.Commit().Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Transaction failed: %w", err)))
+		return
+	}
+	if category.Image != oldImage {
+		ctrl.removeImage(c, oldImage)
+	}
 
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to save new image: %v", err),
-			})
-			return
-		}
+	setETag(c, category.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Category updated successfully",
+		"data":    category,
+	})
+}
 
-		category.Image = newFileName
+// DeleteCategoryImage removes the image of a category.
+func (ctrl *Controller) DeleteCategoryImage(c *gin.Context) {
+	id := c.Param("id")
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
 	}
 
-	if err := tx.Save(&category).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Failed to update category: %v", err),
-		})
+	var category model.FoodCategory
+	if err := ctrl.db(c).First(&category, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeCategoryNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch category: %w", err)))
+		}
 		return
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Transaction failed: %v", err),
-		})
+	if category.CafeId != userID.(uint) {
+		apierr.Respond(c, apierr.Forbidden(apierr.CodeCategoryForbidden))
+		return
+	}
+	if err := checkIfMatch(c, category.Version); err != nil {
+		apierr.Respond(c, err)
 		return
 	}
 
+	if oldImage := category.Image; oldImage != "" {
+		if err := updateVersion(ctrl.db(c), &category, &category.Version, "image", nil); err != nil {
+			apierr.Respond(c, apierr.Wrap(err, "Failed to update category"))
+			return
+		}
+		category.Image = ""
+		ctrl.removeImage(c, oldImage)
+	}
+
+	setETag(c, category.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Category updated successfully",
+		"message": "Category image deleted successfully",
 		"data":    category,
 	})
 }
 
 // DeleteCategory handles deleting a food category and its associated image.
-func DeleteCategory(c *gin.Context) {
+// A category that still has subcategories or foods is only deleted when
+// ?reassign_to names another category of the cafe to move them to.
+func (ctrl *Controller) DeleteCategory(c *gin.Context) {
 	id := c.Param("id")
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "User ID not found in context",
-		})
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
 		return
 	}
 
-	tx := database.DB.Begin()
+	tx := ctrl.db(c).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Unexpected error occurred",
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("panic: %v", r)))
 		}
 	}()
 
 	var category model.FoodCategory
-	if err := tx.First(&category, id).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&category, id).Error; err != nil {
 		tx.Rollback()
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"error":   "Category not found",
-			})
+			apierr.Respond(c, apierr.NotFound(apierr.CodeCategoryNotFound))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to fetch category: %v", err),
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch category: %w", err)))
 		}
 		return
 	}
 
 	if category.CafeId != userID.(uint) {
 		tx.Rollback()
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"error":   "You don't have permission to delete this category",
-		})
+		apierr.Respond(c, apierr.Forbidden(apierr.CodeCategoryForbidden))
+		return
+	}
+	if err := checkIfMatch(c, category.Version); err != nil {
+		tx.Rollback()
+		apierr.Respond(c, err)
 		return
 	}
 
-	if category.Image != "" {
-		if err := os.Remove(filepath.Join(uploadDir, category.Image)); err != nil && !os.IsNotExist(err) {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to delete image: %v", err),
-			})
-			return
-		}
+	if err := checkCategoryUnused(tx, category.ID); err != nil {
+		tx.Rollback()
+		apierr.Respond(c, apierr.Wrap(err, "Failed to check category references"))
+		return
 	}
 
-	if err := tx.Delete(&category).Error; err != nil {
+	if err := releaseCategoryContents(tx, category, c.Query("reassign_to")); err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Failed to delete category: %v", err),
-		})
+		apierr.Respond(c, apierr.Wrap(err, "Failed to reassign category contents"))
+		return
+	}
+
+	if err := tx.Where("category_id = ?", category.ID).Delete(&model.MenuSchedule{}).Error; err != nil {
+		tx.Rollback()
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to delete category schedules: %w", err)))
+		return
+	}
+
+	if err := deleteVersion(tx, &category, category.Version); err != nil {
+		tx.Rollback()
+		apierr.Respond(c, apierr.Wrap(err, "Failed to delete category"))
 		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Transaction failed: %v", err),
-		})
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Transaction failed: %w", err)))
 		return
 	}
 
+	ctrl.removeImage(c, category.Image)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Category deleted successfully",
@@ -344,22 +382,16 @@ func DeleteCategory(c *gin.Context) {
 }
 
 // GetMyCategories retrieves categories for the authenticated user's cafe.
-func GetMyCategories(c *gin.Context) {
+func (ctrl *Controller) GetMyCategories(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "User ID not found in context",
-		})
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
 		return
 	}
 
 	var categories []model.FoodCategory
-	if err := database.DB.Where("cafe_id = ?", userID.(uint)).Find(&categories).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Failed to retrieve categories: %v", err),
-		})
+	if err := ctrl.db(c).Where("cafe_id = ?", userID.(uint)).Preload("Schedules").Order("sort_order, id").Find(&categories).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to retrieve categories: %w", err)))
 		return
 	}
 
@@ -370,27 +402,63 @@ func GetMyCategories(c *gin.Context) {
 	})
 }
 
+// GetMyCategory returns one category of the authenticated cafe with its
+// version in the ETag header, ready to be sent back in If-Match.
+func (ctrl *Controller) GetMyCategory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	var category model.FoodCategory
+	if err := ctrl.db(c).Preload("Schedules").First(&category, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeCategoryNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch category: %w", err)))
+		}
+		return
+	}
+
+	if category.CafeId != userID.(uint) {
+		apierr.Respond(c, apierr.Forbidden(apierr.CodeCategoryForbidden))
+		return
+	}
+
+	setETag(c, category.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Category retrieved successfully",
+		"data":    category,
+	})
+}
+
 // GetCategoriesByCafeID retrieves categories for a specific cafe.
-func GetCategoriesByCafeID(c *gin.Context) {
+func (ctrl *Controller) GetCategoriesByCafeID(c *gin.Context) {
 	cafeIDStr := c.Param("cafe_id")
 	cafeID, err := strconv.Atoi(cafeIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Invalid cafe_id",
-		})
+		apierr.Respond(c, apierr.Field("cafe_id", apierr.CodeInvalidID))
+		return
+	}
+
+	cache, err := ctrl.loadMenuCache(c, cafeID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch cafe: %w", err)))
+		return
+	}
+	if cache.notModified(c) {
 		return
 	}
 
 	var categories []model.FoodCategory
-	if err := database.DB.Where("cafe_id = ?", cafeID).Find(&categories).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Failed to retrieve categories: %v", err),
-		})
+	if err := ctrl.db(c).Where("cafe_id = ?", cafeID).Order("sort_order, id").Find(&categories).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to retrieve categories: %w", err)))
 		return
 	}
 
+	cache.setHeaders(c)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Categories retrieved successfully",
@@ -399,50 +467,135 @@ func GetCategoriesByCafeID(c *gin.Context) {
 }
 
 // GetCafeCategoriesWithFoods retrieves categories and their associated foods for a cafe.
-func GetCafeCategoriesWithFoods(c *gin.Context) {
+func (ctrl *Controller) GetCafeCategoriesWithFoods(c *gin.Context) {
 	cafeID := c.Query("cafe_id")
 	if cafeID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "cafe_id parameter is required",
-		})
+		apierr.Respond(c, apierr.Field("cafe_id", apierr.CodeRequired))
 		return
 	}
 
 	cafeIDUint, err := strconv.ParseUint(cafeID, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Invalid cafe_id format",
-		})
+		apierr.Respond(c, apierr.Field("cafe_id", apierr.CodeInvalidID))
+		return
+	}
+
+	filters, err := parseMenuFilters(c)
+	if err != nil {
+		apierr.Respond(c, err)
 		return
 	}
 
-	type Result struct {
-		model.FoodCategory
-		Foods []model.Food `gorm:"foreignKey:CategoryID" json:"foods"`
+	cache, err := ctrl.loadMenuCache(c, uint(cafeIDUint))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeCafeNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch cafe: %w", err)))
+		}
+		return
+	}
+	if cache.notModified(c) {
+		return
 	}
 
-	var result []Result
-	err = database.DB.
+	opts, err := loadMenuOptions(ctrl.db(c), uint(cafeIDUint), filters)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeCafeNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch cafe: %w", err)))
+		}
+		return
+	}
+
+	var result []categoryNode
+	err = orderedComboSlots(orderedModifiers(ctrl.db(c), "Foods."), "Foods.").
 		Model(&model.FoodCategory{}).
 		Where("cafe_id = ?", uint(cafeIDUint)).
+		Order("sort_order, id").
+		Preload("Schedules").
 		Preload("Foods", func(db *gorm.DB) *gorm.DB {
-			return db.Where("cafe_id = ?", uint(cafeIDUint))
+			return opts.foodScope(db.Where("cafe_id = ?", uint(cafeIDUint))).Order("sort_order, id")
 		}).
+		Preload("Foods.Schedules").
 		Find(&result).Error
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Failed to fetch data: %v", err),
-		})
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch data: %w", err)))
+		return
+	}
+
+	served := buildCategoryTree(result, func(category *categoryNode) bool {
+		if !opts.served(category.Schedules) {
+			return false
+		}
+		category.Foods = opts.prepareFoods(category.Foods)
+		return true
+	})
+
+	cache.setHeaders(c)
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  "Categories and foods retrieved successfully",
+		"data":     served,
+		"currency": opts.cafe.Currency,
+	})
+}
+
+// ReorderCategories stores the order of the given category IDs in one transaction.
+func (ctrl *Controller) ReorderCategories(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	var req reorderRequest
+	if err := c.ShouldBind(&req); err != nil {
+		apierr.Respond(c, apierr.Bind(err))
+		return
+	}
+
+	err := ctrl.db(c).Transaction(func(tx *gorm.DB) error {
+		return applySortOrder(tx, &model.FoodCategory{}, "cafe_id", userID.(uint), req.IDs)
+	})
+	if err != nil {
+		apierr.Respond(c, apierr.Wrap(err, "Failed to reorder categories"))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Categories and foods retrieved successfully",
-		"data":    result,
+		"message": "Categories reordered successfully",
+		"data":    gin.H{"ids": req.IDs},
 	})
 }
+
+// checkCategoryUnused refuses to delete a category that promotions or combo
+// slots still offer, since they would be left pointing at nothing.
+func checkCategoryUnused(tx *gorm.DB, categoryID uint) error {
+	var promotions, combos int64
+	err := tx.Model(&model.PromotionTarget{}).
+		Joins("JOIN promotions ON promotions.id = promotion_targets.promotion_id AND promotions.deleted_at IS NULL").
+		Where("promotion_targets.category_id = ?", categoryID).
+		Distinct("promotion_targets.promotion_id").
+		Count(&promotions).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Model(&model.ComboSlotOption{}).
+		Joins("JOIN combo_slots ON combo_slots.id = combo_slot_options.slot_id AND combo_slots.deleted_at IS NULL").
+		Joins("JOIN foods ON foods.id = combo_slots.combo_id AND foods.deleted_at IS NULL").
+		Where("combo_slot_options.category_id = ?", categoryID).
+		Distinct("combo_slots.combo_id").
+		Count(&combos).Error
+	if err != nil {
+		return err
+	}
+	if promotions > 0 || combos > 0 {
+		return apierr.Conflict(apierr.CodeCategoryInUse, promotions, combos).
+			WithData(map[string]any{"promotions": promotions, "combos": combos})
+	}
+	return nil
+}
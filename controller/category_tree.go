@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"cafe/apierr"
+	"cafe/model"
+	"gorm.io/gorm"
+	"strconv"
+)
+
+// categoryNode is a category of the public menu with its foods and subcategories.
+type categoryNode struct {
+	model.FoodCategory
+	Foods    []model.Food    `gorm:"foreignKey:CategoryID" json:"foods"`
+	Children []*categoryNode `gorm:"-" json:"children"`
+}
+
+// buildCategoryTree nests categories under their parents, keeping the input
+// order among siblings. Categories whose parent is hidden are hidden as well;
+// categories whose parent no longer exists are shown at the top level.
+func buildCategoryTree(all []categoryNode, visible func(*categoryNode) bool) []*categoryNode {
+	nodes := make(map[uint]*categoryNode, len(all))
+	for i := range all {
+		nodes[all[i].ID] = &all[i]
+	}
+
+	shown := map[uint]bool{}
+	var isShown func(node *categoryNode, depth int) bool
+	isShown = func(node *categoryNode, depth int) bool {
+		if result, ok := shown[node.ID]; ok {
+			return result
+		}
+		result := visible(node)
+		if result && node.ParentID != nil && depth < len(all) {
+			if parent, ok := nodes[*node.ParentID]; ok {
+				result = isShown(parent, depth+1)
+			}
+		}
+		shown[node.ID] = result
+		return result
+	}
+
+	roots := []*categoryNode{}
+	for i := range all {
+		all[i].Children = []*categoryNode{}
+	}
+	for i := range all {
+		node := &all[i]
+		if !isShown(node, 0) {
+			continue
+		}
+		if node.ParentID != nil {
+			if parent, ok := nodes[*node.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
+
+// categoryTree holds the parent links of every category of one cafe.
+type categoryTree struct {
+	parents  map[uint]*uint
+	children map[uint][]uint
+}
+
+func loadCategoryTree(db *gorm.DB, cafeID uint) (categoryTree, error) {
+	var categories []model.FoodCategory
+	if err := db.Select("id", "parent_id").Where("cafe_id = ?", cafeID).Find(&categories).Error; err != nil {
+		return categoryTree{}, err
+	}
+
+	tree := categoryTree{parents: map[uint]*uint{}, children: map[uint][]uint{}}
+	for _, category := range categories {
+		tree.parents[category.ID] = category.ParentID
+		if category.ParentID != nil {
+			tree.children[*category.ParentID] = append(tree.children[*category.ParentID], category.ID)
+		}
+	}
+	return tree, nil
+}
+
+func (t categoryTree) contains(id uint) bool {
+	_, ok := t.parents[id]
+	return ok
+}
+
+// depth is 1 for a top-level category.
+func (t categoryTree) depth(id uint) int {
+	depth := 1
+	for parent := t.parents[id]; parent != nil && depth <= len(t.parents); parent = t.parents[*parent] {
+		depth++
+	}
+	return depth
+}
+
+// height is 1 for a category without subcategories.
+func (t categoryTree) height(id uint) int {
+	height := 0
+	for _, child := range t.children[id] {
+		if h := t.height(child); h > height {
+			height = h
+		}
+	}
+	return height + 1
+}
+
+// subtree returns id and the ids of all its descendants.
+func (t categoryTree) subtree(id uint) []uint {
+	ids := []uint{id}
+	for _, child := range t.children[id] {
+		ids = append(ids, t.subtree(child)...)
+	}
+	return ids
+}
+
+// subtreeSet returns the subtree of id as a set, or nil when there is no id.
+func (t categoryTree) subtreeSet(id *uint) model.CategorySet {
+	if id == nil {
+		return nil
+	}
+	set := model.CategorySet{}
+	for _, member := range t.subtree(*id) {
+		set[member] = true
+	}
+	return set
+}
+
+func (t categoryTree) inSubtree(id, root uint) bool {
+	for _, member := range t.subtree(root) {
+		if member == id {
+			return true
+		}
+	}
+	return false
+}
+
+// checkParent validates placing category id, or a new category when id is 0,
+// under parentID.
+func (t categoryTree) checkParent(id, parentID uint) error {
+	if !t.contains(parentID) {
+		return apierr.Field("parent_id", apierr.CodeInvalidParent)
+	}
+	height := 1
+	if id != 0 {
+		if t.inSubtree(parentID, id) {
+			return apierr.Field("parent_id", apierr.CodeCategoryCycle)
+		}
+		height = t.height(id)
+	}
+	if t.depth(parentID)+height > model.MaxCategoryDepth {
+		return apierr.Field("parent_id", apierr.CodeCategoryTooDeep, model.MaxCategoryDepth)
+	}
+	return nil
+}
+
+// releaseCategoryContents moves the subcategories and foods of category to the
+// category named by reassignTo so that it can be deleted without leaving orphans.
+func releaseCategoryContents(tx *gorm.DB, category model.FoodCategory, reassignTo string) error {
+	tree, err := loadCategoryTree(tx, category.CafeId)
+	if err != nil {
+		return err
+	}
+
+	var foods int64
+	if err := tx.Model(&model.Food{}).Where("category_id = ?", category.ID).Count(&foods).Error; err != nil {
+		return err
+	}
+	children := tree.children[category.ID]
+	if len(children) == 0 && foods == 0 {
+		return nil
+	}
+	if reassignTo == "" {
+		return apierr.Conflict(apierr.CodeCategoryNotEmpty, len(children), foods).
+			WithData(map[string]any{"children": len(children), "foods": foods})
+	}
+
+	targetID, err := strconv.ParseUint(reassignTo, 10, 32)
+	if err != nil || !tree.contains(uint(targetID)) {
+		return apierr.Field("reassign_to", apierr.CodeInvalidReassign)
+	}
+	target := uint(targetID)
+	if tree.inSubtree(target, category.ID) {
+		return apierr.Field("reassign_to", apierr.CodeInvalidReassign)
+	}
+	for _, child := range children {
+		if tree.depth(target)+tree.height(child) > model.MaxCategoryDepth {
+			return apierr.Field("reassign_to", apierr.CodeCategoryTooDeep, model.MaxCategoryDepth)
+		}
+	}
+
+	if err := tx.Unscoped().Model(&model.FoodCategory{}).Where("parent_id = ?", category.ID).Update("parent_id", target).Error; err != nil {
+		return err
+	}
+	// Deleted foods move along so that they never point at a deleted category.
+	return tx.Unscoped().Model(&model.Food{}).Where("category_id = ?", category.ID).Update("category_id", target).Error
+}
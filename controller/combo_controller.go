@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"cafe/apierr"
+	"cafe/model"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+type comboRequest struct {
+	Slots []struct {
+		NameTm   string `json:"name_tm"`
+		NameRu   string `json:"name_ru"`
+		Quantity int    `json:"quantity"`
+		Optional bool   `json:"optional"`
+		Options  []struct {
+			FoodID     *uint       `json:"food_id"`
+			CategoryID *uint       `json:"category_id"`
+			PriceDelta model.Money `json:"price_delta"`
+		} `json:"options"`
+	} `json:"slots"`
+}
+
+// orderedComboSlots preloads combo slots with their options in menu order.
+// prefix is the association path leading to the food, e.g. "Foods.".
+func orderedComboSlots(db *gorm.DB, prefix string) *gorm.DB {
+	return db.
+		Preload(prefix+"ComboSlots", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order, id")
+		}).
+		Preload(prefix + "ComboSlots.Options").
+		Preload(prefix + "ComboSlots.Options.Food").
+		Preload(prefix + "ComboSlots.Options.Category")
+}
+
+// SetFoodCombo replaces the slots of a combo food. Sending slots turns the
+// food into a combo, an empty list turns it back into a single item.
+func (ctrl *Controller) SetFoodCombo(c *gin.Context) {
+	food, ok := ctrl.findOwnedFood(c)
+	if !ok {
+		return
+	}
+	if err := checkIfMatch(c, food.Version); err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	var req comboRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Respond(c, apierr.Bind(err))
+		return
+	}
+
+	var slots []model.ComboSlot
+	var foodIDs, categoryIDs []uint
+	for index, item := range req.Slots {
+		slot := model.ComboSlot{
+			ComboID:   food.ID,
+			NameTm:    item.NameTm,
+			NameRu:    item.NameRu,
+			Quantity:  item.Quantity,
+			Optional:  item.Optional,
+			SortOrder: index + 1,
+		}
+		if slot.Quantity == 0 {
+			slot.Quantity = 1
+		}
+		for _, option := range item.Options {
+			slot.Options = append(slot.Options, model.ComboSlotOption{
+				FoodID:     option.FoodID,
+				CategoryID: option.CategoryID,
+				PriceDelta: option.PriceDelta,
+			})
+			if option.FoodID != nil {
+				if *option.FoodID == food.ID {
+					apierr.Respond(c, apierr.Field(fmt.Sprintf("slots[%d].options", index), apierr.CodeComboContainsItself))
+					return
+				}
+				foodIDs = append(foodIDs, *option.FoodID)
+			}
+			if option.CategoryID != nil {
+				categoryIDs = append(categoryIDs, *option.CategoryID)
+			}
+		}
+		if err := slot.Validate(); err != nil {
+			apierr.Respond(c, apierr.At(fmt.Sprintf("slots[%d]", index), err))
+			return
+		}
+		slots = append(slots, slot)
+	}
+
+	if len(foodIDs) > 0 {
+		var count int64
+		err := ctrl.db(c).Model(&model.Food{}).
+			Where("id IN ? AND cafe_id = ? AND kind = ?", foodIDs, food.CafeID, model.FoodSingle).
+			Count(&count).Error
+		if err != nil {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to check combo foods: %w", err)))
+			return
+		}
+		if count != int64(len(uniqueIDs(foodIDs))) {
+			apierr.Respond(c, apierr.Field("slots", apierr.CodeInvalidComboOption))
+			return
+		}
+	}
+	if len(categoryIDs) > 0 {
+		var count int64
+		err := ctrl.db(c).Model(&model.FoodCategory{}).
+			Where("id IN ? AND cafe_id = ?", categoryIDs, food.CafeID).
+			Count(&count).Error
+		if err != nil {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to check combo categories: %w", err)))
+			return
+		}
+		if count != int64(len(uniqueIDs(categoryIDs))) {
+			apierr.Respond(c, apierr.Field("category_id", apierr.CodeInvalidCategory))
+			return
+		}
+	}
+
+	kind := model.FoodSingle
+	if len(slots) > 0 {
+		kind = model.FoodCombo
+	}
+
+	err := ctrl.db(c).Transaction(func(tx *gorm.DB) error {
+		if kind == model.FoodCombo {
+			if err := checkNotComboOption(tx, food.ID); err != nil {
+				return err
+			}
+		}
+		if err := updateVersion(tx, &food, &food.Version, "kind", kind); err != nil {
+			return err
+		}
+		slotIDs := tx.Model(&model.ComboSlot{}).Select("id").Where("combo_id = ?", food.ID)
+		if err := tx.Where("slot_id IN (?)", slotIDs).Delete(&model.ComboSlotOption{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("combo_id = ?", food.ID).Delete(&model.ComboSlot{}).Error; err != nil {
+			return err
+		}
+		if len(slots) > 0 {
+			return tx.Create(&slots).Error
+		}
+		return nil
+	})
+	if err != nil {
+		apierr.Respond(c, apierr.Wrap(err, "Failed to save combo"))
+		return
+	}
+
+	if err := orderedComboSlots(ctrl.db(c), "").First(&food, food.ID).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch combo: %w", err)))
+		return
+	}
+
+	setETag(c, food.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Combo updated successfully",
+		"data":    food,
+	})
+}
+
+// checkNotComboOption refuses to turn a food into a combo while other combos
+// offer it, since a combo cannot contain another combo.
+func checkNotComboOption(tx *gorm.DB, foodID uint) error {
+	var combos int64
+	err := tx.Model(&model.ComboSlotOption{}).
+		Joins("JOIN combo_slots ON combo_slots.id = combo_slot_options.slot_id AND combo_slots.deleted_at IS NULL").
+		Joins("JOIN foods ON foods.id = combo_slots.combo_id AND foods.deleted_at IS NULL").
+		Where("combo_slot_options.food_id = ? AND combo_slots.combo_id <> ?", foodID, foodID).
+		Distinct("combo_slots.combo_id").
+		Count(&combos).Error
+	if err != nil {
+		return err
+	}
+	if combos > 0 {
+		return apierr.Conflict(apierr.CodeFoodInCombo, combos).WithData(map[string]any{"combos": combos})
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"cafe/apierr"
+	"cafe/model"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// isForeignKeyViolation reports whether err comes from a foreign key, e.g. a
+// food saved into a category that was removed in the meantime.
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
+
+// lockCategories checks that the categories foods are put into belong to the
+// cafe and keeps them from being deleted until tx ends. Categories are only
+// soft deleted, so the foreign keys cannot catch a delete racing the write;
+// DeleteCategory locks the row for update and waits for this share lock.
+func lockCategories(tx *gorm.DB, cafeID uint, ids []uint) error {
+	var categories []model.FoodCategory
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "SHARE"}).
+		Select("id", "deleted_at").
+		Where("id IN ? AND cafe_id = ?", ids, cafeID).
+		Find(&categories).Error
+	if err != nil {
+		return err
+	}
+	if len(categories) != len(uniqueIDs(ids)) {
+		return apierr.Field("category_id", apierr.CodeInvalidCategory)
+	}
+	for _, category := range categories {
+		if category.DeletedAt.Valid {
+			return apierr.Conflict(apierr.CodeCategoryGone)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Controller holds what the HTTP handlers share, starting with the database.
+type Controller struct {
+	conn *gorm.DB
+}
+
+func New(db *gorm.DB) *Controller {
+	return &Controller{conn: db}
+}
+
+// db binds the database to the request context, so queries of a request are
+// cancelled when the client goes away.
+func (ctrl *Controller) db(c *gin.Context) *gorm.DB {
+	return ctrl.conn.WithContext(c.Request.Context())
+}
@@ -1,151 +1,180 @@
 package controller
 
 import (
-	"cafe/database"
+	"cafe/apierr"
+	"cafe/metrics"
 	"cafe/model"
+	"cafe/pricing"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/xuri/excelize/v2"
 	"gorm.io/gorm"
+	"log/slog"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 )
 
-func AddFood(c *gin.Context) {
+// foodInput is the body of AddFood and UpdateFood, sent as JSON or as a form.
+// Fields that are left out stay nil and keep their current values; a JSON
+// null clears the fields that may be empty.
+type foodInput struct {
+	nulls
+	CategoryID      *uint        `json:"category_id" form:"category_id"`
+	Price           *model.Money `json:"price" form:"price"`
+	NameTm          *string      `json:"name_tm" form:"name_tm"`
+	NameRu          *string      `json:"name_ru" form:"name_ru"`
+	DescriptionTm   *string      `json:"description_tm" form:"description_tm"`
+	DescriptionRu   *string      `json:"description_ru" form:"description_ru"`
+	SortOrder       *int         `json:"sort_order" form:"sort_order"`
+	SpicyLevel      *int         `json:"spicy_level" form:"spicy_level"`
+	Calories        *int         `json:"calories" form:"calories" binding:"omitempty,min=0"`
+	WeightGrams     *int         `json:"weight_grams" form:"weight_grams" binding:"omitempty,min=0"`
+	VolumeMl        *int         `json:"volume_ml" form:"volume_ml" binding:"omitempty,min=0"`
+	PrepTimeMinutes *int         `json:"prep_time_minutes" form:"prep_time_minutes" binding:"omitempty,min=0"`
+}
+
+// apply copies the names, descriptions, spiciness and nutrition facts of in
+// onto food. Price, category and sort order need the database and are left to
+// the handlers.
+func (in foodInput) apply(food *model.Food) error {
+	if err := in.required("category_id", "price", "sort_order"); err != nil {
+		return err
+	}
+	if in.Price != nil && *in.Price <= 0 {
+		return apierr.Field("price", apierr.CodeInvalidPrice)
+	}
+	if in.SpicyLevel != nil {
+		if *in.SpicyLevel < 0 || *in.SpicyLevel > model.MaxSpicyLevel {
+			return apierr.Field("spicy_level", apierr.CodeOutOfRange, 0, model.MaxSpicyLevel)
+		}
+		food.SpicyLevel = *in.SpicyLevel
+	} else if in.null("spicy_level") {
+		food.SpicyLevel = 0
+	}
+
+	for _, field := range []struct {
+		name   string
+		value  *string
+		target *string
+	}{
+		{"name_tm", in.NameTm, &food.NameTm},
+		{"name_ru", in.NameRu, &food.NameRu},
+		{"description_tm", in.DescriptionTm, &food.DescriptionTm},
+		{"description_ru", in.DescriptionRu, &food.DescriptionRu},
+	} {
+		if field.value != nil {
+			*field.target = *field.value
+		} else if in.null(field.name) {
+			*field.target = ""
+		}
+	}
+	for _, field := range []struct {
+		name   string
+		value  *int
+		target **int
+	}{
+		{"calories", in.Calories, &food.Calories},
+		{"weight_grams", in.WeightGrams, &food.WeightGrams},
+		{"volume_ml", in.VolumeMl, &food.VolumeMl},
+		{"prep_time_minutes", in.PrepTimeMinutes, &food.PrepTimeMinutes},
+	} {
+		if field.value != nil || in.null(field.name) {
+			*field.target = field.value
+		}
+	}
+
+	if food.NameTm == "" && food.NameRu == "" {
+		return apierr.Field("name", apierr.CodeNameRequired)
+	}
+	return nil
+}
+
+func (ctrl *Controller) AddFood(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "User ID not found in context",
-		})
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
 		return
 	}
 
-	price, err := strconv.ParseFloat(c.PostForm("price"), 64)
-	if err != nil || price <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Invalid or missing price",
-		})
+	var in foodInput
+	if err := bindBody(c, &in); err != nil {
+		apierr.Respond(c, err)
 		return
 	}
-
-	categoryID, err := strconv.ParseUint(c.PostForm("category_id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Invalid category ID format",
-		})
+	if in.Price == nil {
+		apierr.Respond(c, apierr.Field("price", apierr.CodeInvalidPrice))
 		return
 	}
-
-	var food model.Food
-	food.CafeID = userID.(uint)
-	food.Price = price
-	food.CategoryID = uint(categoryID)
-	food.NameTm = c.PostForm("name_tm")
-	food.NameRu = c.PostForm("name_ru")
-	food.DescriptionTm = c.PostForm("description_tm")
-	food.DescriptionRu = c.PostForm("description_ru")
-
-	// Validate at least one name is provided
-	if food.NameTm == "" && food.NameRu == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "At least one food name (TM, RU, or EN) is required",
-		})
+	if in.CategoryID == nil {
+		apierr.Respond(c, apierr.Field("category_id", apierr.CodeRequired))
 		return
 	}
 
-	// Validate category belongs to the user's cafe
-	var category model.FoodCategory
-	if err := database.DB.Where("id = ? AND cafe_id = ?", categoryID, userID.(uint)).First(&category).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Invalid category or you don't have permission",
-		})
+	var food model.Food
+	food.CafeID = userID.(uint)
+	food.Price = *in.Price
+	food.CategoryID = *in.CategoryID
+	if err := in.apply(&food); err != nil {
+		apierr.Respond(c, err)
 		return
 	}
 
-	tx := database.DB.Begin()
+	tx := ctrl.db(c).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Unexpected error occurred",
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("panic: %v", r)))
 		}
 	}()
 
-	file, err := c.FormFile("image")
-	if err == nil {
-		if file.Size > 5<<20 { // 5MB limit
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Image size exceeds 5MB limit",
-			})
-			return
-		}
-
-		ext := strings.ToLower(filepath.Ext(file.Filename))
-		allowedExts := map[string]bool{".jpg": true, ".jpeg": true, ".png": true}
-		if !allowedExts[ext] {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Invalid file type, only JPG/JPEG/PNG allowed",
-			})
-			return
-		}
+	if err := lockCategories(tx, userID.(uint), []uint{food.CategoryID}); err != nil {
+		tx.Rollback()
+		apierr.Respond(c, apierr.Wrap(err, "Failed to check category"))
+		return
+	}
 
-		if err := os.MkdirAll(uploadDir, 0755); err != nil {
+	if in.SortOrder != nil {
+		food.SortOrder = *in.SortOrder
+	} else {
+		nextOrder, err := nextSortOrder(tx.Model(&model.Food{}).Where("category_id = ?", food.CategoryID))
+		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to create upload directory: %v", err),
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to determine sort order: %w", err)))
 			return
 		}
+		food.SortOrder = nextOrder
+	}
 
-		newFileName := fmt.Sprintf("food-%d-%d%s", food.CafeID, time.Now().UnixNano(), ext)
-		filePath := filepath.Join(uploadDir, newFileName)
-
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
+	if file, err := c.FormFile("image"); err == nil {
+		metrics.ObserveUpload(metrics.UploadFoodImage, file.Size)
+		image, err := saveImage(c, file, "image", "food", food.CafeID)
+		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to save image: %v", err),
-			})
+			apierr.Respond(c, apierr.Wrap(err, "Image upload failed"))
 			return
 		}
-
-		food.Image = newFileName
+		food.Image = image
 	}
 
 	if err := tx.Create(&food).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Failed to create food: %v", err),
-		})
+		if isForeignKeyViolation(err) {
+			apierr.Respond(c, apierr.Conflict(apierr.CodeCategoryGone))
+			return
+		}
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to create food: %w", err)))
 		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Transaction failed: %v", err),
-		})
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Transaction failed: %w", err)))
 		return
 	}
 
+	setETag(c, food.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Food added successfully",
@@ -153,56 +182,81 @@ func AddFood(c *gin.Context) {
 	})
 }
 
-func BulkAddFood(c *gin.Context) {
+func (ctrl *Controller) BulkAddFood(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "User ID not found in context"})
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
 		return
 	}
 
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Excel file is required"})
+		apierr.Respond(c, apierr.Field("file", apierr.CodeRequired))
 		return
 	}
+	metrics.ObserveUpload(metrics.UploadExcel, fileHeader.Size)
 
 	file, err := fileHeader.Open()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Unable to open Excel file"})
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Unable to open Excel file: %w", err)))
 		return
 	}
 	defer file.Close()
 
 	xl, err := excelize.OpenReader(file)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to parse Excel file"})
+		apierr.Respond(c, apierr.BadRequest(apierr.CodeExcelUnreadable))
 		return
 	}
 
-	rows, err := xl.GetRows("Sheet1")
+	// Raw values keep prices exactly as typed instead of as displayed by the cell format.
+	rows, err := xl.GetRows("Sheet1", excelize.Options{RawCellValue: true})
 	if err != nil || len(rows) < 2 {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Excel must have at least one row of data"})
+		apierr.Respond(c, apierr.BadRequest(apierr.CodeExcelNoRows))
 		return
 	}
 
+	var categoryIDs []uint
+	if err := ctrl.db(c).Model(&model.FoodCategory{}).Where("cafe_id = ?", userID.(uint)).Pluck("id", &categoryIDs).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to load categories: %w", err)))
+		return
+	}
+	ownCategories := make(map[uint]bool, len(categoryIDs))
+	for _, id := range categoryIDs {
+		ownCategories[id] = true
+	}
+
+	skipped := map[string]int{}
+	defer func() {
+		for outcome, n := range skipped {
+			metrics.CountImportRows(outcome, n)
+		}
+	}()
+	skip := func(rowIndex int, outcome string, value string) {
+		skipped[outcome]++
+		slog.DebugContext(c.Request.Context(), "Excel row skipped", "row", rowIndex+2, "reason", outcome, "value", value)
+	}
+
 	var foods []model.Food
 	for rowIndex, row := range rows[1:] {
-		fmt.Printf("Row %d: %v\n", rowIndex+2, row)
-
 		if len(row) < 4 {
-			fmt.Println("⚠️ Incomplete row skipped")
+			skip(rowIndex, metrics.RowIncomplete, strings.Join(row, ","))
 			continue
 		}
 
-		price, err := strconv.ParseFloat(row[1], 64)
+		price, err := model.ParseMoney(row[1])
 		if err != nil || price <= 0 {
-			fmt.Println("❌ Invalid price format:", row[1])
+			skip(rowIndex, metrics.RowInvalidPrice, row[1])
 			continue
 		}
 
 		categoryID, err := strconv.ParseUint(row[0], 10, 32)
 		if err != nil {
-			fmt.Println("❌ Invalid category ID:", row[0])
+			skip(rowIndex, metrics.RowInvalidCategory, row[0])
+			continue
+		}
+		if !ownCategories[uint(categoryID)] {
+			skip(rowIndex, metrics.RowInvalidCategory, row[0])
 			continue
 		}
 
@@ -227,7 +281,7 @@ func BulkAddFood(c *gin.Context) {
 		}
 
 		if food.NameTm == "" && food.NameRu == "" {
-			fmt.Println("⚠️ Both names are empty, skipping")
+			skip(rowIndex, metrics.RowMissingName, "")
 			continue
 		}
 
@@ -235,14 +289,44 @@ func BulkAddFood(c *gin.Context) {
 	}
 
 	if len(foods) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "No valid rows found"})
+		apierr.Respond(c, apierr.BadRequest(apierr.CodeExcelNoValidRows))
 		return
 	}
 
-	if err := database.DB.Create(&foods).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to insert foods"})
+	err = ctrl.db(c).Transaction(func(tx *gorm.DB) error {
+		usedCategories := make([]uint, 0, len(foods))
+		for _, food := range foods {
+			usedCategories = append(usedCategories, food.CategoryID)
+		}
+		if err := lockCategories(tx, userID.(uint), usedCategories); err != nil {
+			return err
+		}
+		// Imported foods go after the existing ones, in the order of the sheet.
+		nextOrder := map[uint]int{}
+		for i := range foods {
+			categoryID := foods[i].CategoryID
+			if _, ok := nextOrder[categoryID]; !ok {
+				order, err := nextSortOrder(tx.Model(&model.Food{}).Where("category_id = ?", categoryID))
+				if err != nil {
+					return err
+				}
+				nextOrder[categoryID] = order
+			}
+			foods[i].SortOrder = nextOrder[categoryID]
+			nextOrder[categoryID]++
+		}
+		return tx.Create(&foods).Error
+	})
+	if err != nil {
+		metrics.CountImportRows(metrics.RowFailed, len(foods))
+		if isForeignKeyViolation(err) {
+			apierr.Respond(c, apierr.Conflict(apierr.CodeCategoryGone))
+			return
+		}
+		apierr.Respond(c, apierr.Wrap(err, "Failed to insert foods"))
 		return
 	}
+	metrics.CountImportRows(metrics.RowImported, len(foods))
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -251,25 +335,25 @@ func BulkAddFood(c *gin.Context) {
 	})
 }
 
-func UpdateFood(c *gin.Context) {
+func (ctrl *Controller) UpdateFood(c *gin.Context) {
 	id := c.Param("id")
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "User ID not found in context",
-		})
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
 		return
 	}
 
-	tx := database.DB.Begin()
+	var in foodInput
+	if err := bindBody(c, &in); err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	tx := ctrl.db(c).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Unexpected error occurred",
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("panic: %v", r)))
 		}
 	}()
 
@@ -277,176 +361,205 @@ func UpdateFood(c *gin.Context) {
 	if err := tx.First(&food, id).Error; err != nil {
 		tx.Rollback()
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"error":   "Food not found",
-			})
+			apierr.Respond(c, apierr.NotFound(apierr.CodeFoodNotFound))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to fetch food: %v", err),
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch food: %w", err)))
 		}
 		return
 	}
 
 	if food.CafeID != userID.(uint) {
 		tx.Rollback()
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"error":   "You don't have permission to update this food item",
-		})
+		apierr.Respond(c, apierr.Forbidden(apierr.CodeFoodForbidden))
 		return
 	}
-
-	if nameTm := c.PostForm("name_tm"); nameTm != "" {
-		food.NameTm = nameTm
-	}
-	if nameRu := c.PostForm("name_ru"); nameRu != "" {
-		food.NameRu = nameRu
-	}
-
-	if descriptionTm := c.PostForm("description_tm"); descriptionTm != "" {
-		food.DescriptionTm = descriptionTm
+	if err := checkIfMatch(c, food.Version); err != nil {
+		tx.Rollback()
+		apierr.Respond(c, err)
+		return
 	}
-	if descriptionRu := c.PostForm("description_ru"); descriptionRu != "" {
-		food.DescriptionRu = descriptionRu
+	if err := in.apply(&food); err != nil {
+		tx.Rollback()
+		apierr.Respond(c, err)
+		return
 	}
 
-	if price := c.PostForm("price"); price != "" {
-		priceFloat, err := strconv.ParseFloat(price, 64)
-		if err != nil || priceFloat <= 0 {
+	if in.Price != nil && *in.Price > 0 {
+		if err := pricing.RecordChange(tx, &food, *in.Price, userID.(uint), model.PriceChangeManual); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Invalid or negative price",
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to record price change: %w", err)))
 			return
 		}
-		food.Price = priceFloat
+		food.Price = *in.Price
 	}
-	if categoryID := c.PostForm("category_id"); categoryID != "" {
-		categoryIDUint, err := strconv.ParseUint(categoryID, 10, 32)
-		if err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Invalid category ID format",
-			})
-			return
-		}
-		var category model.FoodCategory
-		if err := tx.Where("id = ? AND cafe_id = ?", categoryIDUint, userID.(uint)).First(&category).Error; err != nil {
+	if in.CategoryID != nil {
+		if err := lockCategories(tx, userID.(uint), []uint{*in.CategoryID}); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Invalid category or you don't have permission",
-			})
+			apierr.Respond(c, apierr.Wrap(err, "Failed to check category"))
 			return
 		}
-		food.CategoryID = uint(categoryIDUint)
+		food.CategoryID = *in.CategoryID
+	}
+	if in.SortOrder != nil {
+		food.SortOrder = *in.SortOrder
 	}
 
-	file, err := c.FormFile("image")
-	if err == nil {
-		if file.Size > 5<<20 {
+	oldImage := food.Image
+	if file, err := c.FormFile("image"); err == nil {
+		metrics.ObserveUpload(metrics.UploadFoodImage, file.Size)
+		image, err := saveImage(c, file, "image", "food", food.CafeID)
+		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Image size exceeds 5MB limit",
-			})
+			apierr.Respond(c, apierr.Wrap(err, "Image upload failed"))
 			return
 		}
+		food.Image = image
+	}
 
-		ext := strings.ToLower(filepath.Ext(file.Filename))
-		allowedExts := map[string]bool{".jpg": true, ".jpeg": true, ".png": true}
-		if !allowedExts[ext] {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Invalid file type, only JPG/JPEG/PNG allowed",
-			})
-			return
+	if err := saveVersion(tx, &food, &food.Version); err != nil {
+		tx.Rollback()
+		if food.Image != oldImage {
+			ctrl.removeImage(c, food.Image)
 		}
-
-		if err := os.MkdirAll(uploadDir, 0755); err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to create upload directory: %v", err),
-			})
+		if isForeignKeyViolation(err) {
+			apierr.Respond(c, apierr.Conflict(apierr.CodeCategoryGone))
 			return
 		}
+		apierr.Respond(c, apierr.Wrap(err, "Failed to update food"))
+		return
+	}
 
-		if food.Image != "" {
-			if err := os.Remove(filepath.Join(uploadDir, food.Image)); err != nil && !os.IsNotExist(err) {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"error":   fmt.Sprintf("Failed to delete old image: %v", err),
-				})
-				return
-			}
-		}
+	if err := tx.Commit().Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Transaction failed: %w", err)))
+		return
+	}
+	if food.Image != oldImage {
+		ctrl.removeImage(c, oldImage)
+	}
 
-		newFileName := fmt.Sprintf("food-%d-%d%s", food.CafeID, time.Now().UnixNano(), ext)
-		filePath := filepath.Join(uploadDir, newFileName)
+	setETag(c, food.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Food updated successfully",
+		"data":    food,
+	})
+}
 
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to save new image: %v", err),
-			})
-			return
+// UpdateFoodImage replaces the image of a food with an uploaded jpg or png.
+func (ctrl *Controller) UpdateFoodImage(c *gin.Context) {
+	id := c.Param("id")
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		apierr.Respond(c, apierr.Field("image", apierr.CodeRequired))
+		return
+	}
+	metrics.ObserveUpload(metrics.UploadFoodImage, file.Size)
+
+	var food model.Food
+	if err := ctrl.db(c).First(&food, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeFoodNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch food: %w", err)))
 		}
+		return
+	}
 
-		food.Image = newFileName
+	if food.CafeID != userID.(uint) {
+		apierr.Respond(c, apierr.Forbidden(apierr.CodeFoodForbidden))
+		return
+	}
+	if err := checkIfMatch(c, food.Version); err != nil {
+		apierr.Respond(c, err)
+		return
 	}
 
-	if err := tx.Save(&food).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Failed to update food: %v", err),
-		})
+	image, err := saveImage(c, file, "image", "food", food.CafeID)
+	if err != nil {
+		apierr.Respond(c, apierr.Wrap(err, "Image upload failed"))
 		return
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Transaction failed: %v", err),
-		})
+	oldImage := food.Image
+	if err := updateVersion(ctrl.db(c), &food, &food.Version, "image", image); err != nil {
+		ctrl.removeImage(c, image)
+		apierr.Respond(c, apierr.Wrap(err, "Failed to update food"))
 		return
 	}
+	ctrl.removeImage(c, oldImage)
 
+	setETag(c, food.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Food updated successfully",
+		"message": "Food image updated successfully",
+		"data":    food,
+	})
+}
+
+// DeleteFoodImage removes the image of a food.
+func (ctrl *Controller) DeleteFoodImage(c *gin.Context) {
+	id := c.Param("id")
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	var food model.Food
+	if err := ctrl.db(c).First(&food, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeFoodNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch food: %w", err)))
+		}
+		return
+	}
+
+	if food.CafeID != userID.(uint) {
+		apierr.Respond(c, apierr.Forbidden(apierr.CodeFoodForbidden))
+		return
+	}
+	if err := checkIfMatch(c, food.Version); err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	if oldImage := food.Image; oldImage != "" {
+		if err := updateVersion(ctrl.db(c), &food, &food.Version, "image", nil); err != nil {
+			apierr.Respond(c, apierr.Wrap(err, "Failed to update food"))
+			return
+		}
+		food.Image = ""
+		ctrl.removeImage(c, oldImage)
+	}
+
+	setETag(c, food.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Food image deleted successfully",
 		"data":    food,
 	})
 }
 
-func DeleteFood(c *gin.Context) {
+func (ctrl *Controller) DeleteFood(c *gin.Context) {
 	id := c.Param("id")
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "User ID not found in context",
-		})
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
 		return
 	}
 
-	tx := database.DB.Begin()
+	tx := ctrl.db(c).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Unexpected error occurred",
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("panic: %v", r)))
 		}
 	}()
 
@@ -454,55 +567,68 @@ func DeleteFood(c *gin.Context) {
 	if err := tx.First(&food, id).Error; err != nil {
 		tx.Rollback()
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"error":   "Food not found",
-			})
+			apierr.Respond(c, apierr.NotFound(apierr.CodeFoodNotFound))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to fetch food: %v", err),
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch food: %w", err)))
 		}
 		return
 	}
 
 	if food.CafeID != userID.(uint) {
 		tx.Rollback()
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"error":   "You don't have permission to delete this food item",
-		})
+		apierr.Respond(c, apierr.Forbidden(apierr.CodeFoodForbidden))
+		return
+	}
+	if err := checkIfMatch(c, food.Version); err != nil {
+		tx.Rollback()
+		apierr.Respond(c, err)
+		return
+	}
+	if err := checkFoodUnused(tx, food.ID); err != nil {
+		tx.Rollback()
+		apierr.Respond(c, apierr.Wrap(err, "Failed to check where the food is used"))
 		return
 	}
 
-	if food.Image != "" {
-		if err := os.Remove(filepath.Join(uploadDir, food.Image)); err != nil && !os.IsNotExist(err) {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to delete image: %v", err),
-			})
-			return
-		}
+	groupIDs := tx.Model(&model.ModifierGroup{}).Select("id").Where("food_id = ?", food.ID)
+	if err := tx.Where("group_id IN (?)", groupIDs).Delete(&model.ModifierOption{}).Error; err != nil {
+		tx.Rollback()
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to delete modifier options: %w", err)))
+		return
+	}
+	if err := tx.Where("food_id = ?", food.ID).Delete(&model.ModifierGroup{}).Error; err != nil {
+		tx.Rollback()
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to delete modifier groups: %w", err)))
+		return
+	}
+	slotIDs := tx.Model(&model.ComboSlot{}).Select("id").Where("combo_id = ?", food.ID)
+	if err := tx.Where("slot_id IN (?)", slotIDs).Delete(&model.ComboSlotOption{}).Error; err != nil {
+		tx.Rollback()
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to delete combo options: %w", err)))
+		return
+	}
+	if err := tx.Where("combo_id = ?", food.ID).Delete(&model.ComboSlot{}).Error; err != nil {
+		tx.Rollback()
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to delete combo slots: %w", err)))
+		return
+	}
+	if err := tx.Model(&food).Association("Tags").Clear(); err != nil {
+		tx.Rollback()
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to delete food tags: %w", err)))
+		return
 	}
 
-	if err := tx.Delete(&food).Error; err != nil {
+	if err := deleteVersion(tx, &food, food.Version); err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Failed to delete food: %v", err),
-		})
+		apierr.Respond(c, apierr.Wrap(err, "Failed to delete food"))
 		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Transaction failed: %v", err),
-		})
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Transaction failed: %w", err)))
 		return
 	}
+	ctrl.removeImage(c, food.Image)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -511,66 +637,120 @@ func DeleteFood(c *gin.Context) {
 	})
 }
 
-func GetFoodsByCategoryID(c *gin.Context) {
-	categoryID := c.Param("category_id")
+// checkFoodUnused refuses to delete a food that promotions or other combos
+// still offer, which would otherwise be left pointing at nothing.
+func checkFoodUnused(tx *gorm.DB, foodID uint) error {
+	var promotions, combos int64
+	err := tx.Model(&model.PromotionTarget{}).
+		Joins("JOIN promotions ON promotions.id = promotion_targets.promotion_id AND promotions.deleted_at IS NULL").
+		Where("promotion_targets.food_id = ?", foodID).
+		Distinct("promotion_targets.promotion_id").
+		Count(&promotions).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Model(&model.ComboSlotOption{}).
+		Joins("JOIN combo_slots ON combo_slots.id = combo_slot_options.slot_id AND combo_slots.deleted_at IS NULL").
+		Joins("JOIN foods ON foods.id = combo_slots.combo_id AND foods.deleted_at IS NULL").
+		Where("combo_slot_options.food_id = ? AND combo_slots.combo_id <> ?", foodID, foodID).
+		Distinct("combo_slots.combo_id").
+		Count(&combos).Error
+	if err != nil {
+		return err
+	}
+	if promotions > 0 || combos > 0 {
+		return apierr.Conflict(apierr.CodeFoodInUse, promotions, combos).
+			WithData(map[string]any{"promotions": promotions, "combos": combos})
+	}
+	return nil
+}
+
+func (ctrl *Controller) GetFoodsByCategoryID(c *gin.Context) {
+	categoryID := c.Query("category_id")
 	if categoryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Category ID is required",
-		})
+		apierr.Respond(c, apierr.Field("category_id", apierr.CodeRequired))
 		return
 	}
 
 	categoryIDUint, err := strconv.ParseUint(categoryID, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Invalid category ID format",
-		})
+		apierr.Respond(c, apierr.Field("category_id", apierr.CodeInvalidID))
 		return
 	}
 
-	var foods []model.Food
-	if err := database.DB.Where("category_id = ?", uint(categoryIDUint)).Find(&foods).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Failed to fetch foods: %v", err),
-		})
+	filters, err := parseMenuFilters(c)
+	if err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	cache, err := ctrl.loadMenuCache(c, ctrl.db(c).Model(&model.FoodCategory{}).Select("cafe_id").Where("id = ?", uint(categoryIDUint)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeCategoryNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch cafe: %w", err)))
+		}
+		return
+	}
+	if cache.notModified(c) {
+		return
+	}
+
+	var category model.FoodCategory
+	if err := ctrl.db(c).Preload("Schedules").First(&category, uint(categoryIDUint)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeCategoryNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch category: %w", err)))
+		}
 		return
 	}
 
+	opts, err := loadMenuOptions(ctrl.db(c), category.CafeId, filters)
+	if err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch cafe: %w", err)))
+		return
+	}
+
+	foods := []model.Food{}
+	if opts.served(category.Schedules) {
+		query := opts.foodScope(ctrl.db(c).Where("category_id = ?", uint(categoryIDUint)))
+		if err := query.Preload("Schedules").Order("sort_order, id").Find(&foods).Error; err != nil {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch foods: %w", err)))
+			return
+		}
+		foods = opts.prepareFoods(foods)
+	}
+
+	cache.setHeaders(c)
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Foods retrieved successfully",
-		"data":    foods,
+		"success":  true,
+		"message":  "Foods retrieved successfully",
+		"data":     foods,
+		"currency": opts.cafe.Currency,
 	})
 }
 
-func GetMyCafeFoods(c *gin.Context) {
+func (ctrl *Controller) GetMyCafeFoods(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "User ID not found in context",
-		})
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
 		return
 	}
 
 	searchQuery := c.Query("search")
 
 	var foods []model.Food
-	query := database.DB.Where("cafe_id = ?", userID.(uint))
+	query := orderedComboSlots(ctrl.db(c).Where("cafe_id = ?", userID.(uint)).Preload("Schedules").Preload("Tags"), "")
 
 	if searchQuery != "" {
 		searchPattern := "%" + searchQuery + "%"
 		query = query.Where("name_tm ILIKE ? OR name_ru ILIKE ?", searchPattern, searchPattern)
 	}
 
-	if err := query.Find(&foods).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   fmt.Sprintf("Failed to fetch foods: %v", err),
-		})
+	if err := query.Order("category_id, sort_order, id").Find(&foods).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch foods: %w", err)))
 		return
 	}
 
@@ -581,44 +761,227 @@ func GetMyCafeFoods(c *gin.Context) {
 	})
 }
 
-func GetFoodByID(c *gin.Context) {
+// GetMyFood returns one food of the authenticated cafe with its version in
+// the ETag header, ready to be sent back in If-Match.
+func (ctrl *Controller) GetMyFood(c *gin.Context) {
+	food, ok := ctrl.findOwnedFood(c)
+	if !ok {
+		return
+	}
+
+	if err := orderedComboSlots(ctrl.db(c).Preload("Schedules").Preload("Tags"), "").First(&food, food.ID).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch food: %w", err)))
+		return
+	}
+
+	setETag(c, food.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Food retrieved successfully",
+		"data":    food,
+	})
+}
+
+func (ctrl *Controller) GetFoodByID(c *gin.Context) {
 	foodID := c.Param("id")
 	if foodID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Food ID is required",
-		})
+		apierr.Respond(c, apierr.Field("id", apierr.CodeRequired))
 		return
 	}
 
 	foodIDUint, err := strconv.ParseUint(foodID, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Invalid food ID format",
-		})
+		apierr.Respond(c, apierr.Field("id", apierr.CodeInvalidID))
+		return
+	}
+
+	at, err := menuTime(c)
+	if err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	cache, err := ctrl.loadMenuCache(c, ctrl.db(c).Model(&model.Food{}).Select("cafe_id").Where("id = ?", uint(foodIDUint)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeFoodNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch cafe: %w", err)))
+		}
+		return
+	}
+	if cache.notModified(c) {
+		return
+	}
+
+	var food model.Food
+	if err := orderedComboSlots(orderedModifiers(ctrl.db(c).Preload("Schedules").Preload("Tags"), ""), "").First(&food, uint(foodIDUint)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeFoodNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch food: %w", err)))
+		}
+		return
+	}
+
+	opts, err := loadMenuOptions(ctrl.db(c), food.CafeID, menuFilters{at: at})
+	if err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch cafe: %w", err)))
+		return
+	}
+
+	var categorySchedules []model.MenuSchedule
+	if err := ctrl.db(c).Where("category_id = ?", food.CategoryID).Find(&categorySchedules).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch category schedules: %w", err)))
+		return
+	}
+
+	food.IsAvailable = food.Available(opts.at)
+	if !opts.served(categorySchedules) || !opts.served(food.Schedules) ||
+		(!food.IsAvailable && opts.cafe.HideUnavailableFoods) {
+		apierr.Respond(c, apierr.NotFound(apierr.CodeFoodNotFound))
+		return
+	}
+	opts.applyPromotion(&food)
+
+	cache.setHeaders(c)
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  "Food retrieved successfully",
+		"data":     food,
+		"currency": opts.cafe.Currency,
+	})
+}
+
+// ReorderFoods stores the order of the given food IDs in one transaction.
+func (ctrl *Controller) ReorderFoods(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	var req reorderRequest
+	if err := c.ShouldBind(&req); err != nil {
+		apierr.Respond(c, apierr.Bind(err))
+		return
+	}
+
+	err := ctrl.db(c).Transaction(func(tx *gorm.DB) error {
+		return applySortOrder(tx, &model.Food{}, "cafe_id", userID.(uint), req.IDs)
+	})
+	if err != nil {
+		apierr.Respond(c, apierr.Wrap(err, "Failed to reorder foods"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Foods reordered successfully",
+		"data":    gin.H{"ids": req.IDs},
+	})
+}
+
+// SetFoodAvailability puts a food on or takes it off the stop-list.
+// Without an is_available value the current state is toggled.
+func (ctrl *Controller) SetFoodAvailability(c *gin.Context) {
+	id := c.Param("id")
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
 		return
 	}
 
 	var food model.Food
-	if err := database.DB.First(&food, uint(foodIDUint)).Error; err != nil {
+	if err := ctrl.db(c).First(&food, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"error":   "Food not found",
-			})
+			apierr.Respond(c, apierr.NotFound(apierr.CodeFoodNotFound))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Failed to fetch food: %v", err),
-			})
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch food: %w", err)))
 		}
 		return
 	}
 
+	if food.CafeID != userID.(uint) {
+		apierr.Respond(c, apierr.Forbidden(apierr.CodeFoodForbidden))
+		return
+	}
+	if err := checkIfMatch(c, food.Version); err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	isAvailable := !food.Available(time.Now())
+	if value := c.PostForm("is_available"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			apierr.Respond(c, apierr.Field("is_available", apierr.CodeInvalidBoolean))
+			return
+		}
+		isAvailable = parsed
+	}
+
+	var availableAgainAt *time.Time
+	if value := c.PostForm("available_again_at"); value != "" && !isAvailable {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			apierr.Respond(c, apierr.Field("available_again_at", apierr.CodeInvalidDateTime))
+			return
+		}
+		if !parsed.After(time.Now()) {
+			apierr.Respond(c, apierr.Field("available_again_at", apierr.CodeMustBeFuture))
+			return
+		}
+		availableAgainAt = &parsed
+	}
+
+	result := ctrl.db(c).Model(&food).Where("version = ?", food.Version).Updates(map[string]interface{}{
+		"is_available":       isAvailable,
+		"available_again_at": availableAgainAt,
+	})
+	if result.Error != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to update availability: %w", result.Error)))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apierr.Respond(c, apierr.PreconditionFailed(apierr.CodeVersionMismatch))
+		return
+	}
+	food.IsAvailable = isAvailable
+	food.AvailableAgainAt = availableAgainAt
+	// The update bumped the version in the database, see model.Food.BeforeUpdate.
+	food.Version++
+
+	setETag(c, food.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Food retrieved successfully",
+		"message": "Food availability updated successfully",
 		"data":    food,
 	})
 }
+
+// GetStopList returns the foods of the authenticated cafe that cannot be served right now.
+func (ctrl *Controller) GetStopList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	var foods []model.Food
+	err := ctrl.db(c).
+		Where("cafe_id = ? AND is_available = ?", userID.(uint), false).
+		Where("(available_again_at IS NULL OR available_again_at > ?)", time.Now()).
+		Order("category_id, sort_order, id").
+		Find(&foods).Error
+	if err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch stop-list: %w", err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Stop-list retrieved successfully",
+		"data":    foods,
+	})
+}
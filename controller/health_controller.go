@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"cafe/config"
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Healthz reports that the process is up. It deliberately checks nothing else
+// so a slow database does not get the server restarted.
+func (ctrl *Controller) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz reports whether the server can take traffic: the database answers
+// and uploads can be written.
+func (ctrl *Controller) Readyz(c *gin.Context) {
+	checks := gin.H{"database": "ok", "uploads": "ok"}
+	ready := true
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	if err := ctrl.ping(ctx); err != nil {
+		checks["database"] = err.Error()
+		ready = false
+	}
+	if err := checkWritable(config.Current.UploadDir); err != nil {
+		checks["uploads"] = err.Error()
+		ready = false
+	}
+
+	status, text := http.StatusOK, "ok"
+	if !ready {
+		status, text = http.StatusServiceUnavailable, "unavailable"
+	}
+	c.JSON(status, gin.H{"status": text, "checks": checks})
+}
+
+func (ctrl *Controller) ping(ctx context.Context) error {
+	sqlDB, err := ctrl.conn.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func checkWritable(dir string) error {
+	file, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
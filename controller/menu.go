@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"cafe/apierr"
+	"cafe/model"
+	"cafe/pricing"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// menuFilters are the guest-controlled parameters of a public menu request.
+type menuFilters struct {
+	at          time.Time
+	excludeTags []string
+	requireTags []string
+	maxSpicy    *int
+}
+
+// menuOptions describes how a public menu should be built for one cafe.
+type menuOptions struct {
+	menuFilters
+	cafe       model.Cafe
+	promotions []model.Promotion
+	categories categoryTree
+}
+
+// menuTime returns the moment the public menu is built for: now, or the
+// preview time passed as ?at= in RFC3339 format.
+func menuTime(c *gin.Context) (time.Time, error) {
+	value := c.Query("at")
+	if value == "" {
+		return time.Now(), nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, apierr.Field("at", apierr.CodeInvalidDateTime)
+	}
+	return parsed, nil
+}
+
+// parseMenuFilters reads ?at=, ?exclude_tags=nuts,gluten, ?tags=vegan and ?max_spicy=1.
+func parseMenuFilters(c *gin.Context) (menuFilters, error) {
+	var filters menuFilters
+	at, err := menuTime(c)
+	if err != nil {
+		return filters, err
+	}
+	filters.at = at
+	filters.excludeTags = splitQueryList(c.Query("exclude_tags"))
+	filters.requireTags = splitQueryList(c.Query("tags"))
+
+	if value := c.Query("max_spicy"); value != "" {
+		maxSpicy, err := strconv.Atoi(value)
+		if err != nil || maxSpicy < 0 {
+			return filters, apierr.Field("max_spicy", apierr.CodeOutOfRange, 0, model.MaxSpicyLevel)
+		}
+		filters.maxSpicy = &maxSpicy
+	}
+	return filters, nil
+}
+
+func splitQueryList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func loadMenuOptions(db *gorm.DB, cafeID uint, filters menuFilters) (menuOptions, error) {
+	var cafe model.Cafe
+	if err := db.First(&cafe, cafeID).Error; err != nil {
+		return menuOptions{}, err
+	}
+
+	var promotions []model.Promotion
+	err := db.Where("cafe_id = ? AND is_active = ?", cafeID, true).
+		Preload("Targets").
+		Preload("Schedules").
+		Find(&promotions).Error
+	if err != nil {
+		return menuOptions{}, err
+	}
+
+	categories, err := loadCategoryTree(db, cafeID)
+	if err != nil {
+		return menuOptions{}, err
+	}
+	for i := range promotions {
+		for j := range promotions[i].Targets {
+			target := &promotions[i].Targets[j]
+			target.Subtree = categories.subtreeSet(target.CategoryID)
+		}
+	}
+
+	return menuOptions{
+		menuFilters: filters,
+		cafe:        cafe,
+		promotions:  pricing.Running(promotions, filters.at, cafe.Location()),
+		categories:  categories,
+	}, nil
+}
+
+// resolveCombo lets the category options of a combo food cover the foods of
+// subcategories as well.
+func (o menuOptions) resolveCombo(food *model.Food) {
+	for i := range food.ComboSlots {
+		for j := range food.ComboSlots[i].Options {
+			option := &food.ComboSlots[i].Options[j]
+			option.Subtree = o.categories.subtreeSet(option.CategoryID)
+		}
+	}
+}
+
+// foodScope restricts a public food query according to the cafe settings and the guest filters.
+func (o menuOptions) foodScope(db *gorm.DB) *gorm.DB {
+	if o.cafe.HideUnavailableFoods {
+		db = db.Where("(is_available = ? OR available_again_at <= ?)", true, o.at)
+	}
+	if o.maxSpicy != nil {
+		db = db.Where("spicy_level <= ?", *o.maxSpicy)
+	}
+	if len(o.excludeTags) > 0 {
+		db = db.Where("id NOT IN (?)", o.taggedFoods(db, o.excludeTags))
+	}
+	for _, code := range o.requireTags {
+		db = db.Where("id IN (?)", o.taggedFoods(db, []string{code}))
+	}
+	return db.Preload("Tags")
+}
+
+// taggedFoods selects the ids of foods carrying any of the given tag codes
+// visible to the cafe.
+func (o menuOptions) taggedFoods(db *gorm.DB, codes []string) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Table("food_tags").
+		Select("food_tags.food_id").
+		Joins("JOIN tags ON tags.id = food_tags.tag_id").
+		Where("tags.code IN ? AND (tags.cafe_id IS NULL OR tags.cafe_id = ?)", codes, o.cafe.ID)
+}
+
+// served reports whether an item with the given schedules is on the menu at the menu time.
+func (o menuOptions) served(schedules []model.MenuSchedule) bool {
+	return model.ScheduledNow(schedules, o.at.In(o.cafe.Location()))
+}
+
+// prepareFoods drops foods that are not served at the menu time and
+// resolves the effective availability of the remaining ones.
+func (o menuOptions) prepareFoods(foods []model.Food) []model.Food {
+	prepared := make([]model.Food, 0, len(foods))
+	for _, food := range foods {
+		if !o.served(food.Schedules) {
+			continue
+		}
+		food.IsAvailable = food.Available(o.at)
+		o.applyPromotion(&food)
+		prepared = append(prepared, food)
+	}
+	return prepared
+}
+
+// applyPromotion shows the best running single-item discount next to the regular price.
+func (o menuOptions) applyPromotion(food *model.Food) {
+	price, promotion := pricing.BestItemPrice(food, o.promotions)
+	if promotion == nil {
+		return
+	}
+	food.DiscountedPrice = &price
+	food.PromotionID = &promotion.ID
+}
@@ -0,0 +1,256 @@
+package controller
+
+import (
+	"cafe/apierr"
+	"cafe/model"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+type modifierGroupRequest struct {
+	NameTm    string `json:"name_tm"`
+	NameRu    string `json:"name_ru"`
+	MinSelect int    `json:"min_select"`
+	MaxSelect int    `json:"max_select"`
+	SortOrder int    `json:"sort_order"`
+	Options   []struct {
+		NameTm     string      `json:"name_tm"`
+		NameRu     string      `json:"name_ru"`
+		PriceDelta model.Money `json:"price_delta"`
+		IsDefault  bool        `json:"is_default"`
+	} `json:"options"`
+}
+
+func (r modifierGroupRequest) toModel(foodID uint) model.ModifierGroup {
+	group := model.ModifierGroup{
+		FoodID:    foodID,
+		NameTm:    r.NameTm,
+		NameRu:    r.NameRu,
+		MinSelect: r.MinSelect,
+		MaxSelect: r.MaxSelect,
+		SortOrder: r.SortOrder,
+	}
+	for index, option := range r.Options {
+		group.Options = append(group.Options, model.ModifierOption{
+			NameTm:     option.NameTm,
+			NameRu:     option.NameRu,
+			PriceDelta: option.PriceDelta,
+			IsDefault:  option.IsDefault,
+			SortOrder:  index + 1,
+		})
+	}
+	return group
+}
+
+// orderedModifiers preloads modifier groups and their options in menu order.
+// prefix is the association path leading to the food, e.g. "Foods.".
+func orderedModifiers(db *gorm.DB, prefix string) *gorm.DB {
+	return db.
+		Preload(prefix+"ModifierGroups", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order, id")
+		}).
+		Preload(prefix+"ModifierGroups.Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order, id")
+		})
+}
+
+// findOwnedFood loads the food from the :id parameter and makes sure it belongs
+// to the authenticated cafe, writing the error response otherwise.
+func (ctrl *Controller) findOwnedFood(c *gin.Context) (model.Food, bool) {
+	var food model.Food
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return food, false
+	}
+
+	if err := ctrl.db(c).First(&food, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeFoodNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch food: %w", err)))
+		}
+		return food, false
+	}
+
+	if food.CafeID != userID.(uint) {
+		apierr.Respond(c, apierr.Forbidden(apierr.CodeFoodForbidden))
+		return food, false
+	}
+	return food, true
+}
+
+// findModifierGroup loads the :group_id modifier group of the given food.
+func (ctrl *Controller) findModifierGroup(c *gin.Context, food model.Food) (model.ModifierGroup, bool) {
+	var group model.ModifierGroup
+	if err := ctrl.db(c).Where("food_id = ?", food.ID).First(&group, c.Param("group_id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeModifierGroupNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch modifier group: %w", err)))
+		}
+		return group, false
+	}
+	return group, true
+}
+
+func (ctrl *Controller) GetFoodModifiers(c *gin.Context) {
+	food, ok := ctrl.findOwnedFood(c)
+	if !ok {
+		return
+	}
+
+	if err := orderedModifiers(ctrl.db(c), "").First(&food, food.ID).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch modifiers: %w", err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Modifiers retrieved successfully",
+		"data":    food.ModifierGroups,
+	})
+}
+
+func (ctrl *Controller) AddFoodModifier(c *gin.Context) {
+	food, ok := ctrl.findOwnedFood(c)
+	if !ok {
+		return
+	}
+	if err := checkIfMatch(c, food.Version); err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	var req modifierGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Respond(c, apierr.Bind(err))
+		return
+	}
+
+	group := req.toModel(food.ID)
+	if err := group.Validate(); err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	if group.SortOrder == 0 {
+		nextOrder, err := nextSortOrder(ctrl.db(c).Model(&model.ModifierGroup{}).Where("food_id = ?", food.ID))
+		if err != nil {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to determine sort order: %w", err)))
+			return
+		}
+		group.SortOrder = nextOrder
+	}
+
+	err := ctrl.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := touchVersion(tx, &food, &food.Version); err != nil {
+			return err
+		}
+		return tx.Create(&group).Error
+	})
+	if err != nil {
+		apierr.Respond(c, apierr.Wrap(err, "Failed to create modifier group"))
+		return
+	}
+
+	setETag(c, food.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Modifier group added successfully",
+		"data":    group,
+	})
+}
+
+// UpdateFoodModifier replaces a modifier group together with all of its options.
+func (ctrl *Controller) UpdateFoodModifier(c *gin.Context) {
+	food, ok := ctrl.findOwnedFood(c)
+	if !ok {
+		return
+	}
+	if err := checkIfMatch(c, food.Version); err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+	existing, ok := ctrl.findModifierGroup(c, food)
+	if !ok {
+		return
+	}
+
+	var req modifierGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Respond(c, apierr.Bind(err))
+		return
+	}
+
+	group := req.toModel(food.ID)
+	if err := group.Validate(); err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+	group.ID = existing.ID
+	group.CreatedAt = existing.CreatedAt
+	if group.SortOrder == 0 {
+		group.SortOrder = existing.SortOrder
+	}
+
+	err := ctrl.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := touchVersion(tx, &food, &food.Version); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("group_id = ?", group.ID).Delete(&model.ModifierOption{}).Error; err != nil {
+			return err
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(&group).Error
+	})
+	if err != nil {
+		apierr.Respond(c, apierr.Wrap(err, "Failed to update modifier group"))
+		return
+	}
+
+	setETag(c, food.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Modifier group updated successfully",
+		"data":    group,
+	})
+}
+
+func (ctrl *Controller) DeleteFoodModifier(c *gin.Context) {
+	food, ok := ctrl.findOwnedFood(c)
+	if !ok {
+		return
+	}
+	if err := checkIfMatch(c, food.Version); err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+	group, ok := ctrl.findModifierGroup(c, food)
+	if !ok {
+		return
+	}
+
+	err := ctrl.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := touchVersion(tx, &food, &food.Version); err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", group.ID).Delete(&model.ModifierOption{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&group).Error
+	})
+	if err != nil {
+		apierr.Respond(c, apierr.Wrap(err, "Failed to delete modifier group"))
+		return
+	}
+
+	setETag(c, food.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Modifier group deleted successfully",
+		"data":    gin.H{"group_id": group.ID},
+	})
+}
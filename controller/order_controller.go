@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"cafe/apierr"
+	"cafe/model"
+	"cafe/pricing"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+type quoteRequest struct {
+	CafeID uint `json:"cafe_id" binding:"required"`
+	Items  []struct {
+		FoodID    uint   `json:"food_id" binding:"required"`
+		Quantity  int    `json:"quantity" binding:"required,min=1,max=100"`
+		OptionIDs []uint `json:"option_ids"`
+		// ComboChoices fill the slots of a combo food, one entry per picked food.
+		ComboChoices []struct {
+			SlotID uint `json:"slot_id" binding:"required"`
+			FoodID uint `json:"food_id" binding:"required"`
+		} `json:"combo_choices" binding:"dive"`
+	} `json:"items" binding:"required,min=1,dive"`
+}
+
+// QuoteOrder prices an order with the promotions running right now without
+// storing anything, so guests see the same total the cafe will charge.
+func (ctrl *Controller) QuoteOrder(c *gin.Context) {
+	var req quoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Respond(c, apierr.Bind(err))
+		return
+	}
+
+	opts, err := loadMenuOptions(ctrl.db(c), req.CafeID, menuFilters{at: time.Now()})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeCafeNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch cafe: %w", err)))
+		}
+		return
+	}
+
+	foodIDs := make([]uint, 0, len(req.Items))
+	for _, item := range req.Items {
+		foodIDs = append(foodIDs, item.FoodID)
+		for _, choice := range item.ComboChoices {
+			foodIDs = append(foodIDs, choice.FoodID)
+		}
+	}
+
+	var foods []model.Food
+	err = orderedComboSlots(orderedModifiers(ctrl.db(c), ""), "").
+		Where("id IN ? AND cafe_id = ?", foodIDs, req.CafeID).
+		Preload("Schedules").
+		Find(&foods).Error
+	if err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch foods: %w", err)))
+		return
+	}
+	foodsByID := make(map[uint]*model.Food, len(foods))
+	categoryIDs := make([]uint, 0, len(foods))
+	for i := range foods {
+		opts.resolveCombo(&foods[i])
+		foodsByID[foods[i].ID] = &foods[i]
+		categoryIDs = append(categoryIDs, foods[i].CategoryID)
+	}
+
+	var categorySchedules []model.MenuSchedule
+	if err := ctrl.db(c).Where("category_id IN ?", categoryIDs).Find(&categorySchedules).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch category schedules: %w", err)))
+		return
+	}
+	schedulesByCategory := map[uint][]model.MenuSchedule{}
+	for _, schedule := range categorySchedules {
+		schedulesByCategory[*schedule.CategoryID] = append(schedulesByCategory[*schedule.CategoryID], schedule)
+	}
+
+	// orderable writes the error response and returns nil when the food cannot be ordered now.
+	orderable := func(foodID uint) *model.Food {
+		food, ok := foodsByID[foodID]
+		if !ok {
+			apierr.Respond(c, apierr.Field("items", apierr.CodeFoodNotInCafe, foodID))
+			return nil
+		}
+		if !food.Available(opts.at) || !opts.served(food.Schedules) || !opts.served(schedulesByCategory[food.CategoryID]) {
+			apierr.Respond(c, apierr.Conflict(apierr.CodeFoodUnavailable, foodID))
+			return nil
+		}
+		return food
+	}
+
+	items := make([]pricing.OrderItem, 0, len(req.Items))
+	for i, item := range req.Items {
+		food := orderable(item.FoodID)
+		if food == nil {
+			return
+		}
+
+		extras, err := food.SelectionPrice(item.OptionIDs)
+		if err != nil {
+			apierr.Respond(c, apierr.At(fmt.Sprintf("items[%d]", i), err))
+			return
+		}
+
+		if food.Kind == model.FoodCombo || len(item.ComboChoices) > 0 {
+			if food.Kind != model.FoodCombo {
+				apierr.Respond(c, apierr.Field("items", apierr.CodeNotACombo, item.FoodID))
+				return
+			}
+
+			picks := map[uint][]*model.Food{}
+			for _, choice := range item.ComboChoices {
+				picked := orderable(choice.FoodID)
+				if picked == nil {
+					return
+				}
+				picks[choice.SlotID] = append(picks[choice.SlotID], picked)
+			}
+
+			comboExtras, err := food.ComboSelectionPrice(picks)
+			if err != nil {
+				apierr.Respond(c, apierr.At(fmt.Sprintf("items[%d]", i), err))
+				return
+			}
+			extras += comboExtras
+		}
+
+		items = append(items, pricing.OrderItem{Food: *food, Quantity: item.Quantity, Extras: extras})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  "Order priced successfully",
+		"data":     pricing.QuoteOrder(items, opts.promotions),
+		"currency": opts.cafe.Currency,
+	})
+}
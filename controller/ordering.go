@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"cafe/apierr"
+	"gorm.io/gorm"
+)
+
+type reorderRequest struct {
+	IDs []uint `form:"ids" json:"ids" binding:"required,min=1"`
+}
+
+// nextSortOrder returns the position right after the last item matched by query.
+func nextSortOrder(query *gorm.DB) (int, error) {
+	var maxOrder int
+	if err := query.Select("COALESCE(MAX(sort_order), 0)").Scan(&maxOrder).Error; err != nil {
+		return 0, err
+	}
+	return maxOrder + 1, nil
+}
+
+// applySortOrder stores the position of every id in ids as its sort order.
+// All ids must belong to the given cafe, otherwise nothing is changed.
+func applySortOrder(tx *gorm.DB, table interface{}, cafeColumn string, cafeID uint, ids []uint) error {
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return apierr.Field("ids", apierr.CodeInvalidReorder)
+		}
+		seen[id] = true
+	}
+
+	var count int64
+	if err := tx.Model(table).Where("id IN ? AND "+cafeColumn+" = ?", ids, cafeID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count != int64(len(ids)) {
+		return apierr.Field("ids", apierr.CodeInvalidReorder)
+	}
+
+	for index, id := range ids {
+		if err := tx.Model(table).Where("id = ?", id).Update("sort_order", index+1).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
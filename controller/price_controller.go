@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"cafe/apierr"
+	"cafe/model"
+	"cafe/pricing"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+// parseEffectiveAt reads the optional effective_at form field. A nil result
+// means the change applies immediately.
+func parseEffectiveAt(c *gin.Context) (*time.Time, error) {
+	value := c.PostForm("effective_at")
+	if value == "" {
+		return nil, nil
+	}
+	effectiveAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, apierr.Field("effective_at", apierr.CodeInvalidDateTime)
+	}
+	if !effectiveAt.After(time.Now()) {
+		return nil, apierr.Field("effective_at", apierr.CodeMustBeFuture)
+	}
+	return &effectiveAt, nil
+}
+
+// GetFoodPriceHistory returns applied, pending and cancelled price changes of a food, newest first.
+func (ctrl *Controller) GetFoodPriceHistory(c *gin.Context) {
+	food, ok := ctrl.findOwnedFood(c)
+	if !ok {
+		return
+	}
+
+	var changes []model.FoodPriceChange
+	if err := ctrl.db(c).Where("food_id = ?", food.ID).Order("effective_at DESC, id DESC").Find(&changes).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch price history: %w", err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Price history retrieved successfully",
+		"data":    changes,
+	})
+}
+
+// ChangeFoodPrice changes the price of a food now or, with effective_at, at a future moment.
+func (ctrl *Controller) ChangeFoodPrice(c *gin.Context) {
+	food, ok := ctrl.findOwnedFood(c)
+	if !ok {
+		return
+	}
+	if err := checkIfMatch(c, food.Version); err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	price, err := model.ParseMoney(c.PostForm("price"))
+	if err != nil || price <= 0 {
+		apierr.Respond(c, apierr.Field("price", apierr.CodeInvalidPrice))
+		return
+	}
+
+	effectiveAt, err := parseEffectiveAt(c)
+	if err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	changedBy := c.MustGet("user_id").(uint)
+	if effectiveAt != nil {
+		change, err := pricing.SchedulePrice(ctrl.db(c), &food, price, *effectiveAt, changedBy, model.PriceChangeScheduled)
+		if err != nil {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to schedule price change: %w", err)))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Price change scheduled successfully",
+			"data":    change,
+		})
+		return
+	}
+
+	err = ctrl.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := pricing.RecordChange(tx, &food, price, changedBy, model.PriceChangeManual); err != nil {
+			return err
+		}
+		return updateVersion(tx, &food, &food.Version, "price", price)
+	})
+	if err != nil {
+		apierr.Respond(c, apierr.Wrap(err, "Failed to change price"))
+		return
+	}
+	food.Price = price
+
+	setETag(c, food.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Price changed successfully",
+		"data":    food,
+	})
+}
+
+// CancelFoodPriceChange cancels a pending scheduled price change.
+func (ctrl *Controller) CancelFoodPriceChange(c *gin.Context) {
+	food, ok := ctrl.findOwnedFood(c)
+	if !ok {
+		return
+	}
+
+	var change model.FoodPriceChange
+	if err := ctrl.db(c).Where("food_id = ?", food.ID).First(&change, c.Param("change_id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodePriceChangeNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch price change: %w", err)))
+		}
+		return
+	}
+
+	if change.Status != model.PriceChangePending {
+		apierr.Respond(c, apierr.Conflict(apierr.CodePriceChangeNotPending))
+		return
+	}
+
+	result := ctrl.db(c).Model(&change).
+		Where("status = ?", model.PriceChangePending).
+		Update("status", model.PriceChangeCancelled)
+	if result.Error != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to cancel price change: %w", result.Error)))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apierr.Respond(c, apierr.Conflict(apierr.CodePriceChangeNotPending))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Price change cancelled successfully",
+		"data":    change,
+	})
+}
+
+// RaiseCategoryPrices changes the price of every food in a category and its
+// subcategories by a percentage, now or at effective_at. Negative percentages lower prices.
+// It is deliberately unversioned: the new prices are computed from the prices
+// read in the same transaction, so there is no stale copy to guard against.
+func (ctrl *Controller) RaiseCategoryPrices(c *gin.Context) {
+	id := c.Param("id")
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	var category model.FoodCategory
+	if err := ctrl.db(c).First(&category, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeCategoryNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch category: %w", err)))
+		}
+		return
+	}
+
+	if category.CafeId != userID.(uint) {
+		apierr.Respond(c, apierr.Forbidden(apierr.CodeCategoryForbidden))
+		return
+	}
+
+	// Percentages use the same two-decimal format as prices, so the parsed
+	// value is the change in hundredths of a percent.
+	basisPoints, err := model.ParseMoney(c.PostForm("percent"))
+	if err != nil || basisPoints == 0 || basisPoints <= -10000 {
+		apierr.Respond(c, apierr.Field("percent", apierr.CodeInvalidPercent))
+		return
+	}
+
+	effectiveAt, err := parseEffectiveAt(c)
+	if err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	var changed []gin.H
+	err = ctrl.db(c).Transaction(func(tx *gorm.DB) error {
+		tree, err := loadCategoryTree(tx, category.CafeId)
+		if err != nil {
+			return err
+		}
+
+		var foods []model.Food
+		if err := tx.Where("category_id IN ? AND cafe_id = ?", tree.subtree(category.ID), category.CafeId).Find(&foods).Error; err != nil {
+			return err
+		}
+
+		for i := range foods {
+			food := &foods[i]
+			oldPrice := food.Price
+			newPrice := oldPrice.AddPercent(int64(basisPoints))
+			if newPrice <= 0 {
+				newPrice = 1
+			}
+			if newPrice == oldPrice {
+				continue
+			}
+
+			if effectiveAt != nil {
+				if _, err := pricing.SchedulePrice(tx, food, newPrice, *effectiveAt, userID.(uint), model.PriceChangeBulk); err != nil {
+					return err
+				}
+			} else if err := pricing.ChangePrice(tx, food, newPrice, userID.(uint), model.PriceChangeBulk); err != nil {
+				return err
+			}
+			changed = append(changed, gin.H{"food_id": food.ID, "old_price": oldPrice, "new_price": newPrice})
+		}
+		return nil
+	})
+	if err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to change category prices: %w", err)))
+		return
+	}
+
+	message := "Category prices changed successfully"
+	if effectiveAt != nil {
+		message = "Category price changes scheduled successfully"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    changed,
+	})
+}
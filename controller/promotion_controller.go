@@ -0,0 +1,260 @@
+package controller
+
+import (
+	"cafe/apierr"
+	"cafe/model"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+type promotionRequest struct {
+	NameTm   string              `json:"name_tm"`
+	NameRu   string              `json:"name_ru"`
+	Type     model.PromotionType `json:"type"`
+	Value    model.Money         `json:"value"`
+	StartsAt *time.Time          `json:"starts_at"`
+	EndsAt   *time.Time          `json:"ends_at"`
+	IsActive *bool               `json:"is_active"`
+	Targets  []struct {
+		FoodID     *uint `json:"food_id"`
+		CategoryID *uint `json:"category_id"`
+		Quantity   int   `json:"quantity"`
+	} `json:"targets"`
+	Schedules []struct {
+		Weekday   int    `json:"weekday"`
+		StartTime string `json:"start_time"`
+		EndTime   string `json:"end_time"`
+	} `json:"schedules"`
+}
+
+func (r promotionRequest) toModel(cafeID uint) model.Promotion {
+	promotion := model.Promotion{
+		CafeID:   cafeID,
+		NameTm:   r.NameTm,
+		NameRu:   r.NameRu,
+		Type:     r.Type,
+		Value:    r.Value,
+		StartsAt: r.StartsAt,
+		EndsAt:   r.EndsAt,
+		IsActive: r.IsActive == nil || *r.IsActive,
+	}
+	for _, target := range r.Targets {
+		quantity := target.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+		promotion.Targets = append(promotion.Targets, model.PromotionTarget{
+			FoodID:     target.FoodID,
+			CategoryID: target.CategoryID,
+			Quantity:   quantity,
+		})
+	}
+	for _, schedule := range r.Schedules {
+		promotion.Schedules = append(promotion.Schedules, model.MenuSchedule{
+			CafeID:    cafeID,
+			Weekday:   schedule.Weekday,
+			StartTime: schedule.StartTime,
+			EndTime:   schedule.EndTime,
+		})
+	}
+	return promotion
+}
+
+// bindPromotion reads and validates a promotion body for the authenticated
+// cafe, writing the error response when it is invalid.
+func (ctrl *Controller) bindPromotion(c *gin.Context, cafeID uint) (model.Promotion, bool) {
+	var req promotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Respond(c, apierr.Bind(err))
+		return model.Promotion{}, false
+	}
+
+	promotion := req.toModel(cafeID)
+	if err := promotion.Validate(); err != nil {
+		apierr.Respond(c, err)
+		return promotion, false
+	}
+
+	var foodIDs, categoryIDs []uint
+	for _, target := range promotion.Targets {
+		if target.FoodID != nil {
+			foodIDs = append(foodIDs, *target.FoodID)
+		} else {
+			categoryIDs = append(categoryIDs, *target.CategoryID)
+		}
+	}
+	for _, check := range []struct {
+		table interface{}
+		ids   []uint
+	}{
+		{&model.Food{}, foodIDs},
+		{&model.FoodCategory{}, categoryIDs},
+	} {
+		if len(check.ids) == 0 {
+			continue
+		}
+		var count int64
+		if err := ctrl.db(c).Model(check.table).Where("id IN ? AND cafe_id = ?", check.ids, cafeID).Count(&count).Error; err != nil {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to check promotion targets: %w", err)))
+			return promotion, false
+		}
+		if count != int64(len(uniqueIDs(check.ids))) {
+			apierr.Respond(c, apierr.Field("target", apierr.CodeInvalidPromotionTarget))
+			return promotion, false
+		}
+	}
+	return promotion, true
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := ids[:0:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// findOwnedPromotion loads the :id promotion of the authenticated cafe.
+func (ctrl *Controller) findOwnedPromotion(c *gin.Context) (model.Promotion, bool) {
+	var promotion model.Promotion
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return promotion, false
+	}
+
+	if err := ctrl.db(c).First(&promotion, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodePromotionNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch promotion: %w", err)))
+		}
+		return promotion, false
+	}
+
+	if promotion.CafeID != userID.(uint) {
+		apierr.Respond(c, apierr.Forbidden(apierr.CodePromotionForbidden))
+		return promotion, false
+	}
+	return promotion, true
+}
+
+func (ctrl *Controller) GetMyPromotions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	var promotions []model.Promotion
+	err := ctrl.db(c).Where("cafe_id = ?", userID.(uint)).
+		Preload("Targets").
+		Preload("Schedules").
+		Order("id DESC").
+		Find(&promotions).Error
+	if err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to retrieve promotions: %w", err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Promotions retrieved successfully",
+		"data":    promotions,
+	})
+}
+
+func (ctrl *Controller) AddPromotion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	promotion, ok := ctrl.bindPromotion(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	if err := ctrl.db(c).Create(&promotion).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to create promotion: %w", err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Promotion added successfully",
+		"data":    promotion,
+	})
+}
+
+// UpdatePromotion replaces a promotion together with its targets and schedules.
+func (ctrl *Controller) UpdatePromotion(c *gin.Context) {
+	existing, ok := ctrl.findOwnedPromotion(c)
+	if !ok {
+		return
+	}
+
+	promotion, ok := ctrl.bindPromotion(c, existing.CafeID)
+	if !ok {
+		return
+	}
+	promotion.ID = existing.ID
+	promotion.CreatedAt = existing.CreatedAt
+
+	err := ctrl.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := deletePromotionParts(tx, promotion.ID); err != nil {
+			return err
+		}
+		return tx.Save(&promotion).Error
+	})
+	if err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to update promotion: %w", err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Promotion updated successfully",
+		"data":    promotion,
+	})
+}
+
+func (ctrl *Controller) DeletePromotion(c *gin.Context) {
+	promotion, ok := ctrl.findOwnedPromotion(c)
+	if !ok {
+		return
+	}
+
+	err := ctrl.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := deletePromotionParts(tx, promotion.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&promotion).Error
+	})
+	if err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to delete promotion: %w", err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Promotion deleted successfully",
+		"data":    gin.H{"promotion_id": promotion.ID},
+	})
+}
+
+func deletePromotionParts(tx *gorm.DB, promotionID uint) error {
+	if err := tx.Where("promotion_id = ?", promotionID).Delete(&model.PromotionTarget{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("promotion_id = ?", promotionID).Delete(&model.MenuSchedule{}).Error
+}
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"cafe/apierr"
+	"cafe/model"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+type scheduleRequest struct {
+	Schedules []struct {
+		Weekday   int    `json:"weekday"`
+		StartTime string `json:"start_time" binding:"required"`
+		EndTime   string `json:"end_time" binding:"required"`
+	} `json:"schedules" binding:"dive"`
+}
+
+// SetCategorySchedules replaces the serving schedules of a category.
+// An empty list makes the category always visible again.
+func (ctrl *Controller) SetCategorySchedules(c *gin.Context) {
+	id := c.Param("id")
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	var category model.FoodCategory
+	if err := ctrl.db(c).First(&category, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeCategoryNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch category: %w", err)))
+		}
+		return
+	}
+
+	if category.CafeId != userID.(uint) {
+		apierr.Respond(c, apierr.Forbidden(apierr.CodeCategoryForbidden))
+		return
+	}
+	if err := checkIfMatch(c, category.Version); err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	schedules, ok := bindSchedules(c, category.CafeId)
+	if !ok {
+		return
+	}
+	for i := range schedules {
+		schedules[i].CategoryID = &category.ID
+	}
+
+	if !ctrl.replaceSchedules(c, &category, &category.Version, "category_id = ?", category.ID, schedules) {
+		return
+	}
+
+	setETag(c, category.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Category schedules updated successfully",
+		"data":    schedules,
+	})
+}
+
+// SetFoodSchedules replaces the serving schedules of a food.
+// An empty list makes the food always visible again.
+func (ctrl *Controller) SetFoodSchedules(c *gin.Context) {
+	id := c.Param("id")
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	var food model.Food
+	if err := ctrl.db(c).First(&food, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeFoodNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch food: %w", err)))
+		}
+		return
+	}
+
+	if food.CafeID != userID.(uint) {
+		apierr.Respond(c, apierr.Forbidden(apierr.CodeFoodForbidden))
+		return
+	}
+	if err := checkIfMatch(c, food.Version); err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	schedules, ok := bindSchedules(c, food.CafeID)
+	if !ok {
+		return
+	}
+	for i := range schedules {
+		schedules[i].FoodID = &food.ID
+	}
+
+	if !ctrl.replaceSchedules(c, &food, &food.Version, "food_id = ?", food.ID, schedules) {
+		return
+	}
+
+	setETag(c, food.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Food schedules updated successfully",
+		"data":    schedules,
+	})
+}
+
+func bindSchedules(c *gin.Context, cafeID uint) ([]model.MenuSchedule, bool) {
+	var req scheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Respond(c, apierr.Bind(err))
+		return nil, false
+	}
+
+	schedules := make([]model.MenuSchedule, 0, len(req.Schedules))
+	for i, item := range req.Schedules {
+		schedule := model.MenuSchedule{
+			CafeID:    cafeID,
+			Weekday:   item.Weekday,
+			StartTime: item.StartTime,
+			EndTime:   item.EndTime,
+		}
+		if err := schedule.Validate(); err != nil {
+			apierr.Respond(c, apierr.At(fmt.Sprintf("schedules[%d]", i), err))
+			return nil, false
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, true
+}
+
+// replaceSchedules replaces the schedules of owner, a food or category read
+// at *version, and moves owner to the next version.
+func (ctrl *Controller) replaceSchedules(c *gin.Context, owner any, version *uint, ownerQuery string, ownerID uint, schedules []model.MenuSchedule) bool {
+	err := ctrl.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := touchVersion(tx, owner, version); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where(ownerQuery, ownerID).Delete(&model.MenuSchedule{}).Error; err != nil {
+			return err
+		}
+		if len(schedules) == 0 {
+			return nil
+		}
+		return tx.Create(&schedules).Error
+	})
+	if err != nil {
+		apierr.Respond(c, apierr.Wrap(err, "Failed to save schedules"))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"cafe/apierr"
+	"cafe/model"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+var tagCodePattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// visibleTags selects the predefined tags plus the tags of the given cafe.
+func visibleTags(db *gorm.DB, cafeID uint) *gorm.DB {
+	return db.Where("cafe_id IS NULL OR cafe_id = ?", cafeID)
+}
+
+// GetMyTags returns the predefined tags and the tags created by the authenticated cafe.
+func (ctrl *Controller) GetMyTags(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	var tags []model.Tag
+	if err := visibleTags(ctrl.db(c), userID.(uint)).Order("kind, id").Find(&tags).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to retrieve tags: %w", err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Tags retrieved successfully",
+		"data":    tags,
+	})
+}
+
+// GetCafeTags returns the tags guests can filter the menu of a cafe by.
+func (ctrl *Controller) GetCafeTags(c *gin.Context) {
+	cafeID, err := strconv.ParseUint(c.Query("cafe_id"), 10, 32)
+	if err != nil {
+		apierr.Respond(c, apierr.Field("cafe_id", apierr.CodeInvalidID))
+		return
+	}
+
+	cache, err := ctrl.loadMenuCache(c, uint(cafeID))
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch cafe: %w", err)))
+		return
+	}
+	if cache.notModified(c) {
+		return
+	}
+
+	var tags []model.Tag
+	if err := visibleTags(ctrl.db(c), uint(cafeID)).Order("kind, id").Find(&tags).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to retrieve tags: %w", err)))
+		return
+	}
+
+	cache.setHeaders(c)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Tags retrieved successfully",
+		"data":    tags,
+	})
+}
+
+func (ctrl *Controller) AddTag(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	cafeID := userID.(uint)
+	tag := model.Tag{
+		CafeID: &cafeID,
+		Code:   c.PostForm("code"),
+		Kind:   model.TagKind(c.PostForm("kind")),
+		NameTM: c.PostForm("name_tm"),
+		NameRU: c.PostForm("name_ru"),
+		NameEN: c.PostForm("name_en"),
+	}
+
+	if !tagCodePattern.MatchString(tag.Code) {
+		apierr.Respond(c, apierr.Field("code", apierr.CodeInvalidTagCode))
+		return
+	}
+	if tag.Kind != model.TagAllergen && tag.Kind != model.TagDietary {
+		apierr.Respond(c, apierr.Field("kind", apierr.CodeInvalidChoice, "allergen, dietary"))
+		return
+	}
+	if tag.NameTM == "" && tag.NameRU == "" && tag.NameEN == "" {
+		apierr.Respond(c, apierr.Field("name", apierr.CodeNameRequired))
+		return
+	}
+
+	var count int64
+	if err := visibleTags(ctrl.db(c), cafeID).Model(&model.Tag{}).Where("code = ?", tag.Code).Count(&count).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to check tag code: %w", err)))
+		return
+	}
+	if count > 0 {
+		apierr.Respond(c, apierr.Conflict(apierr.CodeTagCodeTaken))
+		return
+	}
+
+	if err := ctrl.db(c).Create(&tag).Error; err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to create tag: %w", err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Tag added successfully",
+		"data":    tag,
+	})
+}
+
+func (ctrl *Controller) DeleteTag(c *gin.Context) {
+	id := c.Param("id")
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
+		return
+	}
+
+	var tag model.Tag
+	if err := ctrl.db(c).First(&tag, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierr.Respond(c, apierr.NotFound(apierr.CodeTagNotFound))
+		} else {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch tag: %w", err)))
+		}
+		return
+	}
+
+	if tag.CafeID == nil || *tag.CafeID != userID.(uint) {
+		apierr.Respond(c, apierr.Forbidden(apierr.CodeTagForbidden))
+		return
+	}
+
+	err := ctrl.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&tag).Association("Foods").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+	if err != nil {
+		apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to delete tag: %w", err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Tag deleted successfully",
+		"data":    gin.H{"tag_id": id},
+	})
+}
+
+// SetFoodTags replaces the tags of a food with the given tag IDs.
+func (ctrl *Controller) SetFoodTags(c *gin.Context) {
+	food, ok := ctrl.findOwnedFood(c)
+	if !ok {
+		return
+	}
+	if err := checkIfMatch(c, food.Version); err != nil {
+		apierr.Respond(c, err)
+		return
+	}
+
+	var req struct {
+		TagIDs []uint `form:"tag_ids" json:"tag_ids"`
+	}
+	if err := c.ShouldBind(&req); err != nil {
+		apierr.Respond(c, apierr.Bind(err))
+		return
+	}
+
+	var tags []model.Tag
+	if len(req.TagIDs) > 0 {
+		if err := visibleTags(ctrl.db(c), food.CafeID).Where("id IN ?", req.TagIDs).Find(&tags).Error; err != nil {
+			apierr.Respond(c, apierr.Internal(fmt.Errorf("Failed to fetch tags: %w", err)))
+			return
+		}
+		if len(tags) != len(req.TagIDs) {
+			apierr.Respond(c, apierr.Field("tag_ids", apierr.CodeInvalidTag))
+			return
+		}
+	}
+
+	err := ctrl.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := touchVersion(tx, &food, &food.Version); err != nil {
+			return err
+		}
+		return tx.Model(&food).Association("Tags").Replace(tags)
+	})
+	if err != nil {
+		apierr.Respond(c, apierr.Wrap(err, "Failed to update food tags"))
+		return
+	}
+	food.Tags = tags
+
+	setETag(c, food.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Food tags updated successfully",
+		"data":    food,
+	})
+}
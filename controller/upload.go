@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"cafe/apierr"
+	"cafe/config"
+	"crypto/sha256"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var imageExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true}
+
+// saveImage stores an uploaded jpg or png image under a name made of prefix,
+// cafeID and a hash of its content and returns the name. A name therefore
+// always stands for the same image, and uploading an image again reuses the
+// stored file. field names the form field in validation errors.
+func saveImage(c *gin.Context, file *multipart.FileHeader, field, prefix string, cafeID uint) (string, error) {
+	if file.Size > config.Current.MaxUploadBytes() {
+		return "", apierr.Field(field, apierr.CodeFileTooLarge, config.Current.MaxUploadMB)
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if !imageExts[ext] {
+		return "", apierr.Field(field, apierr.CodeInvalidFileType)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	defer src.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, src); err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	name := fmt.Sprintf("%s-%d-%x%s", prefix, cafeID, hash.Sum(nil)[:16], ext)
+	path := filepath.Join(config.Current.UploadDir, name)
+	if _, err := os.Stat(path); err == nil {
+		return name, nil
+	}
+
+	if err := os.MkdirAll(config.Current.UploadDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	// Written aside and renamed, so that the name is never served half written.
+	tmp, err := os.CreateTemp(config.Current.UploadDir, ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+	_, err = io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+	return name, nil
+}
+
+// removeImage deletes an image that no record points at anymore, such as one
+// replaced by a new upload. Records with the same image share its file, so
+// the file stays while any of them still uses it. A failure is only logged.
+func (ctrl *Controller) removeImage(c *gin.Context, name string) {
+	if name == "" {
+		return
+	}
+	var used bool
+	err := ctrl.db(c).Raw(`SELECT
+		EXISTS (SELECT 1 FROM foods WHERE image = ? AND deleted_at IS NULL) OR
+		EXISTS (SELECT 1 FROM food_categories WHERE image = ? AND deleted_at IS NULL) OR
+		EXISTS (SELECT 1 FROM caves WHERE logo = ? AND deleted_at IS NULL)`, name, name, name).
+		Scan(&used).Error
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Failed to check whether an image is still used", "file", name, "error", err)
+		return
+	}
+	if used {
+		return
+	}
+	if err := os.Remove(filepath.Join(config.Current.UploadDir, name)); err != nil && !os.IsNotExist(err) {
+		slog.WarnContext(c.Request.Context(), "Failed to delete image", "file", name, "error", err)
+	}
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"cafe/apierr"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// etag is the entity tag of a food, category or cafe at version.
+func etag(version uint) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+func setETag(c *gin.Context, version uint) {
+	c.Header("ETag", etag(version))
+}
+
+// checkIfMatch fails with 412 when the request has an If-Match header that
+// does not name version. Requests without the header are not checked.
+func checkIfMatch(c *gin.Context, version uint) error {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return nil
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == etag(version) {
+			return nil
+		}
+	}
+	setETag(c, version)
+	return apierr.PreconditionFailed(apierr.CodeVersionMismatch)
+}
+
+// saveVersion saves every column of record, which was read at *version, and
+// moves it to the next version. It fails with 412 when the row was changed
+// since it was read.
+func saveVersion(tx *gorm.DB, record any, version *uint) error {
+	read := *version
+	*version = read + 1
+	result := tx.Select("*").Where("version = ?", read).Save(record)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apierr.PreconditionFailed(apierr.CodeVersionMismatch)
+	}
+	return nil
+}
+
+// deleteVersion deletes record unless it was changed since it was read at
+// version.
+func deleteVersion(tx *gorm.DB, record any, version uint) error {
+	result := tx.Where("version = ?", version).Delete(record)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apierr.PreconditionFailed(apierr.CodeVersionMismatch)
+	}
+	return nil
+}
+
+// updateVersion sets one column of record, which was read at *version, and
+// moves it to the next version. It fails with 412 when the row was changed
+// since it was read.
+func updateVersion(tx *gorm.DB, record any, version *uint, column string, value any) error {
+	result := tx.Model(record).Where("version = ?", *version).Update(column, value)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apierr.PreconditionFailed(apierr.CodeVersionMismatch)
+	}
+	*version++
+	return nil
+}
+
+// touchVersion moves record, which was read at *version, to the next version
+// when something it owns, such as its tags or schedules, changed. It fails
+// with 412 when the row was changed since it was read.
+func touchVersion(tx *gorm.DB, record any, version *uint) error {
+	return updateVersion(tx, record, version, "updated_at", time.Now())
+}
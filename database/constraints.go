@@ -0,0 +1,75 @@
+package database
+
+import (
+	"fmt"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// foreignKeys link the tables AutoMigrate leaves unrelated because the models
+// only carry plain ID columns. Foods and subcategories reference their
+// category together with the cafe, so a row can never point at a category of
+// another cafe. NO ACTION keeps a category from being removed while foods or
+// subcategories still use it, but lets a cafe delete cascade through both, and
+// on through the schedules, modifiers, combo slots and tags below them (see
+// migration 0003). Categories and foods are only soft deleted by the API, which
+// these keys cannot see; the controllers lock the category row instead.
+// GORM pluralizes Cafe as "caves", which is the table the cafe keys point to.
+var foreignKeys = []struct{ table, name, definition string }{
+	{"food_categories", "fk_food_categories_cafe", "FOREIGN KEY (cafe_id) REFERENCES caves(id) ON DELETE CASCADE"},
+	{"food_categories", "fk_food_categories_parent", "FOREIGN KEY (parent_id, cafe_id) REFERENCES food_categories(id, cafe_id) ON DELETE NO ACTION"},
+	{"foods", "fk_foods_cafe", "FOREIGN KEY (cafe_id) REFERENCES caves(id) ON DELETE CASCADE"},
+	{"foods", "fk_foods_category", "FOREIGN KEY (category_id, cafe_id) REFERENCES food_categories(id, cafe_id) ON DELETE NO ACTION"},
+}
+
+// ensureForeignKeys adds missing constraints as NOT VALID, so that existing
+// orphans do not stop the server from starting, and then tries to validate
+// them. Constraints that stay unvalidated are still enforced for new rows.
+func ensureForeignKeys(db *gorm.DB) error {
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_food_categories_id_cafe ON food_categories (id, cafe_id)").Error; err != nil {
+		return err
+	}
+
+	for _, fk := range foreignKeys {
+		var count int64
+		if err := db.Raw("SELECT COUNT(*) FROM pg_constraint WHERE conname = ?", fk.name).Scan(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		sql := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s NOT VALID", fk.table, fk.name, fk.definition)
+		if err := db.Exec(sql).Error; err != nil {
+			return err
+		}
+	}
+
+	pending, err := ValidateForeignKeys(db)
+	if err != nil {
+		return err
+	}
+	for _, name := range pending {
+		slog.Warn("Çäklendirme barlanmady, ýetim ýazgylar bar: cafe check-integrity -repair", "constraint", name)
+	}
+	return nil
+}
+
+// ValidateForeignKeys validates constraints added as NOT VALID and returns the
+// names of those that still fail because of orphaned rows.
+func ValidateForeignKeys(db *gorm.DB) ([]string, error) {
+	var pending []string
+	for _, fk := range foreignKeys {
+		var validated []bool
+		if err := db.Raw("SELECT convalidated FROM pg_constraint WHERE conname = ?", fk.name).Scan(&validated).Error; err != nil {
+			return nil, err
+		}
+		if len(validated) == 0 || validated[0] {
+			continue
+		}
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s", fk.table, fk.name)).Error; err != nil {
+			pending = append(pending, fk.name)
+		}
+	}
+	return pending, nil
+}
@@ -1,50 +1,112 @@
 package database
 
 import (
+	"cafe/config"
+	"cafe/logging"
 	"cafe/model"
-	"log"
-	"os"
+	"fmt"
+	"log/slog"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
-
-func InitDatabase() {
-	var err error
-
-	dsn := os.Getenv("DATABASE_DSN")
-	if dsn == "" {
-		dsn = "host=localhost user=postgres password=63946229 dbname=cafe port=5432 sslmode=disable"
-	}
-
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+// Connect opens the database connection without touching the schema.
+func Connect(dsn string) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logging.NewGormLogger(config.Current.SlowQueryThreshold),
 	})
 	if err != nil {
-		log.Fatalf("Bazanyň birikdirilmegi şowsuz boldy! Ýalňyşlyk: %v", err)
+		return nil, fmt.Errorf("Bazanyň birikdirilmegi şowsuz boldy! Ýalňyşlyk: %w", err)
 	}
 
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Bazanyň birikdirilmegi şowsuz boldy! Ýalňyşlyk: %v", err)
+		return nil, fmt.Errorf("Bazanyň birikdirilmegi şowsuz boldy! Ýalňyşlyk: %w", err)
 	}
 	if err := sqlDB.Ping(); err != nil {
-		log.Fatalf("Maglumat bazasyna birikmek mümkin däl: %v", err)
+		sqlDB.Close()
+		return nil, fmt.Errorf("Maglumat bazasyna birikmek mümkin däl: %w", err)
+	}
+	return db, nil
+}
+
+// InitDatabase connects and refuses to continue while migrations are pending;
+// the schema is changed only by the migrate command.
+func InitDatabase(dsn string) (*gorm.DB, error) {
+	db, err := Connect(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := PendingMigrations(db)
+	if err == nil && len(pending) > 0 {
+		err = fmt.Errorf("%d sany migrasiýa garaşýar, ilki `cafe migrate up` işlediň", len(pending))
+	} else if err != nil {
+		err = fmt.Errorf("Migrasiýalary barlamak şowsuz boldy: %w", err)
+	}
+	if err != nil {
+		Close(db)
+		return nil, err
 	}
 
-	err = DB.AutoMigrate(
-		&model.Cafe{},
-		&model.CafePhone{},
-		&model.User{},
-		&model.FoodCategory{},
-		&model.Food{},
-	)
+	slog.Info("Bazanyň birikdirilmegi üstünlikli tamamlandy!")
+	return db, nil
+}
+
+// Close releases the connection pool of db.
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Migrasiýa şowsuz boldy: %v", err)
+		return err
 	}
+	return sqlDB.Close()
+}
 
-	log.Println("Bazanyň birikdirilmegi we migrasiýasy üstünlikli tamamlandy!")
+func seedPredefinedTags(db *gorm.DB) error {
+	for _, tag := range model.PredefinedTags {
+		var existing model.Tag
+		err := db.Where("cafe_id IS NULL AND code = ?", tag.Code).
+			Attrs(tag).
+			FirstOrCreate(&existing).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moneyColumns used to be stored as float64 and now hold model.Money minor units.
+var moneyColumns = []struct{ table, column string }{
+	{"foods", "price"},
+	{"modifier_options", "price_delta"},
+}
+
+// migrateMoneyColumns converts legacy floating point price columns to integer
+// minor units before AutoMigrate adopts a legacy schema, since AutoMigrate
+// would otherwise cast them to bigint without scaling.
+func migrateMoneyColumns(db *gorm.DB) error {
+	for _, money := range moneyColumns {
+		var dataType string
+		err := db.Raw(
+			"SELECT data_type FROM information_schema.columns WHERE table_schema = CURRENT_SCHEMA() AND table_name = ? AND column_name = ?",
+			money.table, money.column,
+		).Scan(&dataType).Error
+		if err != nil {
+			return err
+		}
+		if dataType != "double precision" && dataType != "real" && dataType != "numeric" {
+			continue
+		}
+
+		sql := fmt.Sprintf(
+			"ALTER TABLE %s ALTER COLUMN %s TYPE bigint USING ROUND(%s::numeric * 100)::bigint",
+			money.table, money.column, money.column,
+		)
+		if err := db.Exec(sql).Error; err != nil {
+			return err
+		}
+		slog.Info("Sütün teňňelere geçirildi", "table", money.table, "column", money.column)
+	}
+	return nil
 }
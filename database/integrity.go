@@ -0,0 +1,148 @@
+package database
+
+import (
+	"cafe/model"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// UncategorizedName names the category that collects foods whose category is gone.
+const UncategorizedName = "Uncategorized"
+
+// IntegrityIssue is a row that points at a cafe or category that does not exist,
+// belongs to another cafe or has been deleted.
+type IntegrityIssue struct {
+	Table   string `json:"table"`
+	ID      uint   `json:"id"`
+	CafeID  uint   `json:"cafe_id"`
+	Problem string `json:"problem"`
+}
+
+func (i IntegrityIssue) String() string {
+	return fmt.Sprintf("%s #%d (cafe %d): %s", i.Table, i.ID, i.CafeID, i.Problem)
+}
+
+var integrityChecks = []struct {
+	table, problem, query string
+}{
+	{
+		"food_categories", "cafe does not exist",
+		`SELECT fc.id, fc.cafe_id FROM food_categories fc
+		LEFT JOIN caves c ON c.id = fc.cafe_id
+		WHERE c.id IS NULL`,
+	},
+	{
+		"foods", "cafe does not exist",
+		`SELECT f.id, f.cafe_id FROM foods f
+		LEFT JOIN caves c ON c.id = f.cafe_id
+		WHERE c.id IS NULL`,
+	},
+	{
+		"foods", "category does not exist or belongs to another cafe",
+		`SELECT f.id, f.cafe_id FROM foods f
+		LEFT JOIN food_categories fc ON fc.id = f.category_id AND fc.cafe_id = f.cafe_id
+		WHERE fc.id IS NULL`,
+	},
+	{
+		"foods", "category is deleted",
+		`SELECT f.id, f.cafe_id FROM foods f
+		JOIN food_categories fc ON fc.id = f.category_id AND fc.cafe_id = f.cafe_id
+		WHERE f.deleted_at IS NULL AND fc.deleted_at IS NOT NULL`,
+	},
+	{
+		"food_categories", "parent does not exist or belongs to another cafe",
+		`SELECT fc.id, fc.cafe_id FROM food_categories fc
+		LEFT JOIN food_categories p ON p.id = fc.parent_id AND p.cafe_id = fc.cafe_id
+		WHERE fc.parent_id IS NOT NULL AND p.id IS NULL`,
+	},
+	{
+		"food_categories", "parent is deleted",
+		`SELECT fc.id, fc.cafe_id FROM food_categories fc
+		JOIN food_categories p ON p.id = fc.parent_id AND p.cafe_id = fc.cafe_id
+		WHERE fc.deleted_at IS NULL AND p.deleted_at IS NOT NULL`,
+	},
+}
+
+// CheckIntegrity lists every row that breaks the links between cafes,
+// categories and foods.
+func CheckIntegrity(db *gorm.DB) ([]IntegrityIssue, error) {
+	var issues []IntegrityIssue
+	for _, check := range integrityChecks {
+		var rows []struct {
+			ID     uint
+			CafeID uint
+		}
+		if err := db.Raw(check.query).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			issues = append(issues, IntegrityIssue{Table: check.table, ID: row.ID, CafeID: row.CafeID, Problem: check.problem})
+		}
+	}
+	return issues, nil
+}
+
+// RepairIntegrity moves orphaned foods into an "Uncategorized" category of
+// their cafe and turns subcategories with a missing parent into top-level
+// categories. Rows whose cafe no longer exists are left for a person to decide
+// about. It returns the number of repaired rows.
+func RepairIntegrity(db *gorm.DB) (int, error) {
+	issues, err := CheckIntegrity(db)
+	if err != nil {
+		return 0, err
+	}
+
+	missingCafe := map[string]map[uint]bool{"foods": {}, "food_categories": {}}
+	for _, issue := range issues {
+		if issue.Problem == "cafe does not exist" {
+			missingCafe[issue.Table][issue.ID] = true
+		}
+	}
+
+	repaired := 0
+	err = db.Transaction(func(tx *gorm.DB) error {
+		uncategorized := map[uint]uint{}
+		for _, issue := range issues {
+			if missingCafe[issue.Table][issue.ID] {
+				continue
+			}
+			switch issue.Table {
+			case "foods":
+				categoryID, ok := uncategorized[issue.CafeID]
+				if !ok {
+					category, err := uncategorizedCategory(tx, issue.CafeID)
+					if err != nil {
+						return err
+					}
+					categoryID = category.ID
+					uncategorized[issue.CafeID] = categoryID
+				}
+				if err := tx.Unscoped().Model(&model.Food{}).Where("id = ?", issue.ID).Update("category_id", categoryID).Error; err != nil {
+					return err
+				}
+			case "food_categories":
+				if err := tx.Unscoped().Model(&model.FoodCategory{}).Where("id = ?", issue.ID).Update("parent_id", nil).Error; err != nil {
+					return err
+				}
+			}
+			if err := model.TouchCafe(tx, issue.CafeID); err != nil {
+				return err
+			}
+			repaired++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return repaired, nil
+}
+
+func uncategorizedCategory(tx *gorm.DB, cafeID uint) (model.FoodCategory, error) {
+	var category model.FoodCategory
+	err := tx.Where("cafe_id = ? AND parent_id IS NULL AND name_en = ?", cafeID, UncategorizedName).
+		Attrs(model.FoodCategory{NameTM: "Kategoriýasyz", NameRU: "Без категории"}).
+		FirstOrCreate(&category, model.FoodCategory{CafeId: cafeID, NameEN: UncategorizedName}).Error
+	return category, err
+}
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS "combo_slot_options";
+DROP TABLE IF EXISTS "combo_slots";
+DROP TABLE IF EXISTS "food_price_changes";
+DROP TABLE IF EXISTS "modifier_options";
+DROP TABLE IF EXISTS "modifier_groups";
+DROP TABLE IF EXISTS "menu_schedules";
+DROP TABLE IF EXISTS "promotion_targets";
+DROP TABLE IF EXISTS "promotions";
+DROP TABLE IF EXISTS "food_tags";
+DROP TABLE IF EXISTS "tags";
+DROP TABLE IF EXISTS "foods";
+DROP TABLE IF EXISTS "food_categories";
+DROP TABLE IF EXISTS "users";
+DROP TABLE IF EXISTS "cafe_phones";
+DROP TABLE IF EXISTS "caves";
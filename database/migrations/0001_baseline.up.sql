@@ -0,0 +1,270 @@
+-- Baseline: the schema AutoMigrate produced before versioned migrations.
+-- GORM pluralizes Cafe as "caves".
+
+CREATE TABLE "caves" (
+	"id" bigserial,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"deleted_at" timestamptz,
+	"login" text,
+	"password" text,
+	"name" text,
+	"user_role" text,
+	"logo" text,
+	"code" text,
+	"expiry_date" timestamptz,
+	"hide_unavailable_foods" boolean NOT NULL DEFAULT false,
+	"timezone" text NOT NULL DEFAULT 'Asia/Ashgabat',
+	"currency" varchar(3) NOT NULL DEFAULT 'TMT',
+	PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_caves_deleted_at" ON "caves" ("deleted_at");
+
+CREATE TABLE "cafe_phones" (
+	"id" bigserial,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"deleted_at" timestamptz,
+	"cafe_id" bigint,
+	"phone_number" text,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_caves_phone_numbers" FOREIGN KEY ("cafe_id") REFERENCES "caves"("id")
+);
+CREATE INDEX "idx_cafe_phones_deleted_at" ON "cafe_phones" ("deleted_at");
+
+CREATE TABLE "users" (
+	"id" bigserial,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"deleted_at" timestamptz,
+	"user_code" text,
+	"avatar" text,
+	"first_name" text,
+	"last_name" text,
+	"email" text,
+	"business_logo" text,
+	"business_name" text,
+	"country_id" bigint,
+	"city_id" bigint,
+	"address" text,
+	"status" text,
+	"role" text,
+	"phone_number" text,
+	"password" text,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_users_email" ON "users" ("email");
+CREATE INDEX "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE "food_categories" (
+	"id" bigserial,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"deleted_at" timestamptz,
+	"name_tm" text,
+	"name_ru" text,
+	"name_en" text,
+	"image" text,
+	"cafe_id" bigint,
+	"sort_order" bigint DEFAULT 0,
+	"parent_id" bigint,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_food_categories_parent_id" ON "food_categories" ("parent_id");
+CREATE INDEX "idx_food_categories_sort_order" ON "food_categories" ("sort_order");
+CREATE INDEX "idx_food_categories_deleted_at" ON "food_categories" ("deleted_at");
+CREATE UNIQUE INDEX "idx_food_categories_id_cafe" ON "food_categories" ("id", "cafe_id");
+ALTER TABLE "food_categories" ADD CONSTRAINT "fk_food_categories_cafe" FOREIGN KEY ("cafe_id") REFERENCES "caves"("id") ON DELETE CASCADE;
+ALTER TABLE "food_categories" ADD CONSTRAINT "fk_food_categories_parent" FOREIGN KEY ("parent_id", "cafe_id") REFERENCES "food_categories"("id", "cafe_id") ON DELETE NO ACTION;
+
+CREATE TABLE "foods" (
+	"id" bigserial,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"deleted_at" timestamptz,
+	"cafe_id" bigint,
+	"category_id" bigint,
+	"image" text,
+	"price" bigint,
+	"name_tm" text,
+	"name_ru" text,
+	"description_tm" text,
+	"description_ru" text,
+	"sort_order" bigint DEFAULT 0,
+	"is_available" boolean NOT NULL DEFAULT true,
+	"available_again_at" timestamptz,
+	"spicy_level" bigint NOT NULL DEFAULT 0,
+	"calories" bigint,
+	"weight_grams" bigint,
+	"volume_ml" bigint,
+	"prep_time_minutes" bigint,
+	"kind" varchar(16) NOT NULL DEFAULT 'single',
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_foods_cafe" FOREIGN KEY ("cafe_id") REFERENCES "caves"("id") ON DELETE CASCADE,
+	CONSTRAINT "fk_foods_category" FOREIGN KEY ("category_id", "cafe_id") REFERENCES "food_categories"("id", "cafe_id") ON DELETE NO ACTION
+);
+CREATE INDEX "idx_foods_sort_order" ON "foods" ("sort_order");
+CREATE INDEX "idx_foods_deleted_at" ON "foods" ("deleted_at");
+
+CREATE TABLE "tags" (
+	"id" bigserial,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"deleted_at" timestamptz,
+	"cafe_id" bigint,
+	"code" varchar(64),
+	"kind" varchar(16),
+	"name_tm" text,
+	"name_ru" text,
+	"name_en" text,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_tags_code" ON "tags" ("code");
+CREATE INDEX "idx_tags_cafe_id" ON "tags" ("cafe_id");
+CREATE INDEX "idx_tags_deleted_at" ON "tags" ("deleted_at");
+
+CREATE TABLE "food_tags" (
+	"tag_id" bigint,
+	"food_id" bigint,
+	PRIMARY KEY ("tag_id", "food_id"),
+	CONSTRAINT "fk_food_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id"),
+	CONSTRAINT "fk_food_tags_food" FOREIGN KEY ("food_id") REFERENCES "foods"("id")
+);
+
+CREATE TABLE "promotions" (
+	"id" bigserial,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"deleted_at" timestamptz,
+	"cafe_id" bigint,
+	"name_tm" text,
+	"name_ru" text,
+	"type" varchar(16),
+	"value" bigint,
+	"starts_at" timestamptz,
+	"ends_at" timestamptz,
+	"is_active" boolean NOT NULL DEFAULT true,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_promotions_cafe_id" ON "promotions" ("cafe_id");
+CREATE INDEX "idx_promotions_deleted_at" ON "promotions" ("deleted_at");
+
+CREATE TABLE "promotion_targets" (
+	"id" bigserial,
+	"promotion_id" bigint,
+	"food_id" bigint,
+	"category_id" bigint,
+	"quantity" bigint NOT NULL DEFAULT 1,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_promotions_targets" FOREIGN KEY ("promotion_id") REFERENCES "promotions"("id")
+);
+CREATE INDEX "idx_promotion_targets_promotion_id" ON "promotion_targets" ("promotion_id");
+
+CREATE TABLE "menu_schedules" (
+	"id" bigserial,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"deleted_at" timestamptz,
+	"cafe_id" bigint,
+	"category_id" bigint,
+	"food_id" bigint,
+	"promotion_id" bigint,
+	"weekday" bigint,
+	"start_time" varchar(5),
+	"end_time" varchar(5),
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_promotions_schedules" FOREIGN KEY ("promotion_id") REFERENCES "promotions"("id"),
+	CONSTRAINT "fk_food_categories_schedules" FOREIGN KEY ("category_id") REFERENCES "food_categories"("id"),
+	CONSTRAINT "fk_foods_schedules" FOREIGN KEY ("food_id") REFERENCES "foods"("id")
+);
+CREATE INDEX "idx_menu_schedules_promotion_id" ON "menu_schedules" ("promotion_id");
+CREATE INDEX "idx_menu_schedules_food_id" ON "menu_schedules" ("food_id");
+CREATE INDEX "idx_menu_schedules_category_id" ON "menu_schedules" ("category_id");
+CREATE INDEX "idx_menu_schedules_cafe_id" ON "menu_schedules" ("cafe_id");
+CREATE INDEX "idx_menu_schedules_deleted_at" ON "menu_schedules" ("deleted_at");
+
+CREATE TABLE "modifier_groups" (
+	"id" bigserial,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"deleted_at" timestamptz,
+	"food_id" bigint,
+	"name_tm" text,
+	"name_ru" text,
+	"min_select" bigint,
+	"max_select" bigint,
+	"sort_order" bigint DEFAULT 0,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_foods_modifier_groups" FOREIGN KEY ("food_id") REFERENCES "foods"("id")
+);
+CREATE INDEX "idx_modifier_groups_food_id" ON "modifier_groups" ("food_id");
+CREATE INDEX "idx_modifier_groups_deleted_at" ON "modifier_groups" ("deleted_at");
+
+CREATE TABLE "modifier_options" (
+	"id" bigserial,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"deleted_at" timestamptz,
+	"group_id" bigint,
+	"name_tm" text,
+	"name_ru" text,
+	"price_delta" bigint,
+	"is_default" boolean,
+	"sort_order" bigint DEFAULT 0,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_modifier_groups_options" FOREIGN KEY ("group_id") REFERENCES "modifier_groups"("id")
+);
+CREATE INDEX "idx_modifier_options_group_id" ON "modifier_options" ("group_id");
+CREATE INDEX "idx_modifier_options_deleted_at" ON "modifier_options" ("deleted_at");
+
+CREATE TABLE "food_price_changes" (
+	"id" bigserial,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"deleted_at" timestamptz,
+	"food_id" bigint,
+	"cafe_id" bigint,
+	"old_price" bigint,
+	"new_price" bigint,
+	"changed_by" bigint,
+	"source" varchar(16),
+	"status" varchar(16),
+	"effective_at" timestamptz,
+	"applied_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_food_price_changes_effective_at" ON "food_price_changes" ("effective_at");
+CREATE INDEX "idx_food_price_changes_status" ON "food_price_changes" ("status");
+CREATE INDEX "idx_food_price_changes_cafe_id" ON "food_price_changes" ("cafe_id");
+CREATE INDEX "idx_food_price_changes_food_id" ON "food_price_changes" ("food_id");
+CREATE INDEX "idx_food_price_changes_deleted_at" ON "food_price_changes" ("deleted_at");
+
+CREATE TABLE "combo_slots" (
+	"id" bigserial,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"deleted_at" timestamptz,
+	"combo_id" bigint,
+	"name_tm" text,
+	"name_ru" text,
+	"quantity" bigint NOT NULL DEFAULT 1,
+	"optional" boolean,
+	"sort_order" bigint DEFAULT 0,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_foods_combo_slots" FOREIGN KEY ("combo_id") REFERENCES "foods"("id")
+);
+CREATE INDEX "idx_combo_slots_combo_id" ON "combo_slots" ("combo_id");
+CREATE INDEX "idx_combo_slots_deleted_at" ON "combo_slots" ("deleted_at");
+
+CREATE TABLE "combo_slot_options" (
+	"id" bigserial,
+	"slot_id" bigint,
+	"food_id" bigint,
+	"category_id" bigint,
+	"price_delta" bigint,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_combo_slot_options_food" FOREIGN KEY ("food_id") REFERENCES "foods"("id"),
+	CONSTRAINT "fk_combo_slot_options_category" FOREIGN KEY ("category_id") REFERENCES "food_categories"("id"),
+	CONSTRAINT "fk_combo_slots_options" FOREIGN KEY ("slot_id") REFERENCES "combo_slots"("id")
+);
+CREATE INDEX "idx_combo_slot_options_slot_id" ON "combo_slot_options" ("slot_id");
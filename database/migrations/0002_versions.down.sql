@@ -0,0 +1,3 @@
+ALTER TABLE "foods" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "food_categories" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "caves" DROP COLUMN IF EXISTS "version";
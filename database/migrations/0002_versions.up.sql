@@ -0,0 +1,5 @@
+-- Versions for optimistic locking: writes send the version they read in
+-- If-Match and fail with 412 when the row has changed since.
+ALTER TABLE "caves" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "food_categories" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "foods" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
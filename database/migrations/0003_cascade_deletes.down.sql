@@ -0,0 +1,13 @@
+ALTER TABLE "combo_slot_options" DROP CONSTRAINT IF EXISTS "fk_combo_slots_options", ADD CONSTRAINT "fk_combo_slots_options" FOREIGN KEY ("slot_id") REFERENCES "combo_slots"("id");
+ALTER TABLE "combo_slot_options" DROP CONSTRAINT IF EXISTS "fk_combo_slot_options_category", ADD CONSTRAINT "fk_combo_slot_options_category" FOREIGN KEY ("category_id") REFERENCES "food_categories"("id");
+ALTER TABLE "combo_slot_options" DROP CONSTRAINT IF EXISTS "fk_combo_slot_options_food", ADD CONSTRAINT "fk_combo_slot_options_food" FOREIGN KEY ("food_id") REFERENCES "foods"("id");
+ALTER TABLE "combo_slots" DROP CONSTRAINT IF EXISTS "fk_foods_combo_slots", ADD CONSTRAINT "fk_foods_combo_slots" FOREIGN KEY ("combo_id") REFERENCES "foods"("id");
+ALTER TABLE "modifier_options" DROP CONSTRAINT IF EXISTS "fk_modifier_groups_options", ADD CONSTRAINT "fk_modifier_groups_options" FOREIGN KEY ("group_id") REFERENCES "modifier_groups"("id");
+ALTER TABLE "modifier_groups" DROP CONSTRAINT IF EXISTS "fk_foods_modifier_groups", ADD CONSTRAINT "fk_foods_modifier_groups" FOREIGN KEY ("food_id") REFERENCES "foods"("id");
+ALTER TABLE "menu_schedules" DROP CONSTRAINT IF EXISTS "fk_foods_schedules", ADD CONSTRAINT "fk_foods_schedules" FOREIGN KEY ("food_id") REFERENCES "foods"("id");
+ALTER TABLE "menu_schedules" DROP CONSTRAINT IF EXISTS "fk_food_categories_schedules", ADD CONSTRAINT "fk_food_categories_schedules" FOREIGN KEY ("category_id") REFERENCES "food_categories"("id");
+ALTER TABLE "menu_schedules" DROP CONSTRAINT IF EXISTS "fk_promotions_schedules", ADD CONSTRAINT "fk_promotions_schedules" FOREIGN KEY ("promotion_id") REFERENCES "promotions"("id");
+ALTER TABLE "promotion_targets" DROP CONSTRAINT IF EXISTS "fk_promotions_targets", ADD CONSTRAINT "fk_promotions_targets" FOREIGN KEY ("promotion_id") REFERENCES "promotions"("id");
+ALTER TABLE "food_tags" DROP CONSTRAINT IF EXISTS "fk_food_tags_food", ADD CONSTRAINT "fk_food_tags_food" FOREIGN KEY ("food_id") REFERENCES "foods"("id");
+ALTER TABLE "food_tags" DROP CONSTRAINT IF EXISTS "fk_food_tags_tag", ADD CONSTRAINT "fk_food_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id");
+ALTER TABLE "cafe_phones" DROP CONSTRAINT IF EXISTS "fk_caves_phone_numbers", ADD CONSTRAINT "fk_caves_phone_numbers" FOREIGN KEY ("cafe_id") REFERENCES "caves"("id");
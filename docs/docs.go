@@ -0,0 +1,55 @@
+// Package docs serves the OpenAPI specification of the API and a page that
+// renders it. The specification is kept by hand in openapi.yaml; a test in the
+// route package fails when a registered route is missing from it.
+package docs
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+//go:embed index.html
+var indexHTML []byte
+
+var (
+	specOnce sync.Once
+	specJSON []byte
+	specErr  error
+)
+
+// Spec returns the specification as JSON.
+func Spec() ([]byte, error) {
+	specOnce.Do(func() {
+		var doc any
+		if err := yaml.Unmarshal(specYAML, &doc); err != nil {
+			specErr = fmt.Errorf("parse openapi.yaml: %w", err)
+			return
+		}
+		specJSON, specErr = json.Marshal(doc)
+	})
+	return specJSON, specErr
+}
+
+// SpecHandler serves the specification as JSON.
+func SpecHandler(c *gin.Context) {
+	spec, err := Spec()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+}
+
+// UIHandler serves the interactive documentation for /openapi.json.
+func UIHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", indexHTML)
+}
@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogin(t *testing.T) {
+	e := newEnv(t)
+	e.createCafe("plov", "secret")
+
+	e.form(http.MethodPost, "/cafe/auth/login", "", url.Values{"login": {"plov"}, "password": {"wrong"}}).
+		expect(http.StatusUnauthorized)
+	e.form(http.MethodPost, "/cafe/auth/login", "", url.Values{"login": {"nobody"}, "password": {"secret"}}).
+		expect(http.StatusUnauthorized)
+	e.form(http.MethodPost, "/cafe/auth/login", "", url.Values{"login": {"plov"}}).
+		expect(http.StatusBadRequest)
+
+	token := e.login("plov", "secret")
+	e.get("/cafe/my-cafe", token).expect(http.StatusOK)
+	e.get("/cafe/my-cafe", "").expect(http.StatusUnauthorized)
+	e.get("/cafe/my-cafe", "not-a-token").expect(http.StatusUnauthorized)
+}
+
+func TestRefreshToken(t *testing.T) {
+	e := newEnv(t)
+	e.createCafe("plov", "secret")
+
+	res := e.form(http.MethodPost, "/cafe/auth/login", "", url.Values{"login": {"plov"}, "password": {"secret"}}).
+		expect(http.StatusOK)
+	var tokens struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	res.decode(&tokens)
+
+	e.form(http.MethodPost, "/cafe/refresh-token", "", url.Values{"refresh_token": {"garbage"}}).
+		expect(http.StatusUnauthorized)
+
+	res = e.form(http.MethodPost, "/cafe/refresh-token", "", url.Values{"refresh_token": {tokens.RefreshToken}}).
+		expect(http.StatusOK)
+	var refreshed struct {
+		AccessToken string `json:"access_token"`
+	}
+	res.decode(&refreshed)
+	if refreshed.AccessToken == "" {
+		t.Fatal("refresh returned no access token")
+	}
+	e.get("/cafe/my-cafe", refreshed.AccessToken).expect(http.StatusOK)
+}
+
+func TestUpdateCafeLogo(t *testing.T) {
+	e := newEnv(t)
+	e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+
+	res := e.multipart(http.MethodPut, "/cafe/update", token, url.Values{"name": {"Plov House"}}, map[string]upload{
+		"logo": {"logo.png", []byte("\x89PNG\r\n\x1a\n")},
+	}).expect(http.StatusOK)
+	var cafe struct {
+		Name string `json:"name"`
+		Logo string `json:"logo"`
+	}
+	res.data(&cafe)
+	if cafe.Name != "Plov House" {
+		t.Errorf("name = %q, want Plov House", cafe.Name)
+	}
+	if cafe.Logo == "" {
+		t.Fatal("logo was not stored")
+	}
+	if _, err := os.Stat(filepath.Join(e.uploadDir(), cafe.Logo)); err != nil {
+		t.Errorf("logo file: %v", err)
+	}
+}
+
+func TestUpdateCafeCurrency(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+
+	// Money has two decimal places, so yen prices would be off by a hundred.
+	e.form(http.MethodPut, "/cafe/update", token, url.Values{"currency": {"JPY"}}).expect(http.StatusBadRequest)
+	e.form(http.MethodPut, "/cafe/update", token, url.Values{"currency": {"USD"}}).expect(http.StatusOK)
+
+	if err := e.db.First(&cafe, cafe.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if cafe.Currency != "USD" {
+		t.Errorf("currency = %s, want USD", cafe.Currency)
+	}
+}
@@ -0,0 +1,276 @@
+package integration
+
+import (
+	"cafe/model"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCategoryCRUD(t *testing.T) {
+	e := newEnv(t)
+	e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+
+	res := e.multipart(http.MethodPost, "/cafe/cafe/category/add", token, url.Values{
+		"name_tm": {"Içgiler"}, "name_ru": {"Напитки"}, "name_en": {"Drinks"},
+	}, nil).expect(http.StatusOK)
+	var drinks model.FoodCategory
+	res.data(&drinks)
+
+	res = e.multipart(http.MethodPost, "/cafe/cafe/category/add", token, url.Values{
+		"name_tm": {"Gyzgyn"}, "name_ru": {"Горячие"}, "name_en": {"Hot"},
+		"parent_id": {fmt.Sprint(drinks.ID)},
+	}, nil).expect(http.StatusOK)
+	var hot model.FoodCategory
+	res.data(&hot)
+	if hot.ParentID == nil || *hot.ParentID != drinks.ID {
+		t.Fatalf("parent_id = %v, want %d", hot.ParentID, drinks.ID)
+	}
+
+	e.multipart(http.MethodPut, fmt.Sprintf("/cafe/cafe/category/update/%d", drinks.ID), token, url.Values{
+		"parent_id": {fmt.Sprint(hot.ID)},
+	}, nil).expect(http.StatusBadRequest)
+
+	e.multipart(http.MethodPut, fmt.Sprintf("/cafe/cafe/category/update/%d", hot.ID), token, url.Values{
+		"name_en": {"Hot drinks"}, "parent_id": {"0"},
+	}, nil).expect(http.StatusOK)
+
+	var stored model.FoodCategory
+	if err := e.db.First(&stored, hot.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.NameEN != "Hot drinks" || stored.ParentID != nil {
+		t.Errorf("updated category = %q parent %v, want %q at the top level", stored.NameEN, stored.ParentID, "Hot drinks")
+	}
+
+	var mine []model.FoodCategory
+	e.get("/cafe/cafe/categories/get-my", token).expect(http.StatusOK).data(&mine)
+	if len(mine) != 2 {
+		t.Errorf("got %d categories, want 2", len(mine))
+	}
+
+	e.do(http.MethodDelete, fmt.Sprintf("/cafe/cafe/category/delete/%d", hot.ID), token, "", nil).expect(http.StatusOK)
+	e.do(http.MethodDelete, fmt.Sprintf("/cafe/cafe/category/delete/%d", hot.ID), token, "", nil).expect(http.StatusNotFound)
+}
+
+func TestCategoryPatch(t *testing.T) {
+	e := newEnv(t)
+	e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+
+	var drinks, hot model.FoodCategory
+	e.json(http.MethodPost, "/cafe/cafe/category/add", token, map[string]any{
+		"name_tm": "Içgiler", "name_en": "Drinks",
+	}).expect(http.StatusOK).data(&drinks)
+	e.multipart(http.MethodPost, "/cafe/cafe/category/add", token, url.Values{
+		"name_tm": {"Gyzgyn"}, "name_en": {"Hot"}, "parent_id": {fmt.Sprint(drinks.ID)},
+	}, map[string]upload{"image": {"hot.png", []byte("\x89PNG")}}).expect(http.StatusOK).data(&hot)
+
+	path := fmt.Sprintf("/cafe/cafe/category/update/%d", hot.ID)
+	e.json(http.MethodPatch, path, token, map[string]any{"sort_order": nil}).expect(http.StatusBadRequest)
+	e.json(http.MethodPatch, path, token, map[string]any{
+		"name_en": nil, "parent_id": nil,
+	}).expect(http.StatusOK).data(&hot)
+	if hot.NameEN != "" || hot.NameTM != "Gyzgyn" || hot.ParentID != nil {
+		t.Errorf("patched category = %+v", hot)
+	}
+
+	e.do(http.MethodDelete, fmt.Sprintf("/cafe/categories/%d/image", hot.ID), token, "", nil).expect(http.StatusOK).data(&hot)
+	var stored model.FoodCategory
+	if err := e.db.First(&stored, hot.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if hot.Image != "" || stored.Image != "" {
+		t.Errorf("image = %q, stored %q; want none", hot.Image, stored.Image)
+	}
+}
+
+func TestDeleteCategoryWithContents(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+	rice := e.createCategory(cafe.ID, "Rice", &mains.ID)
+	soups := e.createCategory(cafe.ID, "Soups", nil)
+	plov := e.createFood(cafe.ID, mains.ID, "Plov", 4500)
+
+	res := e.do(http.MethodDelete, fmt.Sprintf("/cafe/cafe/category/delete/%d", mains.ID), token, "", nil).
+		expect(http.StatusConflict)
+	var conflict struct {
+		Children int64 `json:"children"`
+		Foods    int64 `json:"foods"`
+	}
+	res.data(&conflict)
+	if conflict.Children != 1 || conflict.Foods != 1 {
+		t.Errorf("conflict = %+v, want one child and one food", conflict)
+	}
+
+	e.do(http.MethodDelete, fmt.Sprintf("/cafe/cafe/category/delete/%d?reassign_to=%d", mains.ID, rice.ID), token, "", nil).
+		expect(http.StatusBadRequest)
+	e.do(http.MethodDelete, fmt.Sprintf("/cafe/cafe/category/delete/%d?reassign_to=%d", mains.ID, soups.ID), token, "", nil).
+		expect(http.StatusOK)
+
+	var moved model.Food
+	if err := e.db.First(&moved, plov.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if moved.CategoryID != soups.ID {
+		t.Errorf("food category = %d, want %d", moved.CategoryID, soups.ID)
+	}
+	var child model.FoodCategory
+	if err := e.db.First(&child, rice.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if child.ParentID == nil || *child.ParentID != soups.ID {
+		t.Errorf("subcategory parent = %v, want %d", child.ParentID, soups.ID)
+	}
+}
+
+func TestCategoryOwnership(t *testing.T) {
+	e := newEnv(t)
+	owner := e.createCafe("plov", "secret")
+	e.createCafe("somsa", "secret")
+	token := e.login("somsa", "secret")
+
+	category := e.createCategory(owner.ID, "Mains", nil)
+
+	e.multipart(http.MethodPut, fmt.Sprintf("/cafe/cafe/category/update/%d", category.ID), token, url.Values{
+		"name_en": {"Stolen"},
+	}, nil).expect(http.StatusForbidden)
+	e.do(http.MethodDelete, fmt.Sprintf("/cafe/cafe/category/delete/%d", category.ID), token, "", nil).
+		expect(http.StatusForbidden)
+	e.multipart(http.MethodPost, "/cafe/cafe/category/add", token, url.Values{
+		"name_en": {"Sub"}, "parent_id": {fmt.Sprint(category.ID)},
+	}, nil).expect(http.StatusBadRequest)
+}
+
+func TestCategoryScheduleVersion(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+	breakfast := e.createCategory(cafe.ID, "Breakfast", nil)
+
+	res := e.do(http.MethodGet, fmt.Sprintf("/cafe/cafe/categories/get-my/%d", breakfast.ID), token, "", nil).
+		expect(http.StatusOK)
+	if tag := res.Header().Get("ETag"); tag != `"1"` {
+		t.Fatalf("ETag = %s, want \"1\"", tag)
+	}
+
+	schedules := fmt.Sprintf("/cafe/cafe/category/schedules/%d", breakfast.ID)
+	body := `{"schedules": [{"weekday": 1, "start_time": "08:00", "end_time": "11:00"}]}`
+	put := func(tag string) *response {
+		req := httptest.NewRequest(http.MethodPut, schedules, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", tag)
+		rec := httptest.NewRecorder()
+		e.router.ServeHTTP(rec, req)
+		return &response{t, rec}
+	}
+	if tag := put(`"1"`).expect(http.StatusOK).Header().Get("ETag"); tag != `"2"` {
+		t.Errorf("ETag after replacing the schedules = %s, want \"2\"", tag)
+	}
+	put(`"1"`).expect(http.StatusPreconditionFailed)
+}
+
+func TestAddFoodToDeletedCategory(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+	if err := e.db.Delete(&mains).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var body errorBody
+	e.json(http.MethodPost, "/cafe/foods/add", token, map[string]any{
+		"category_id": mains.ID, "price": 45, "name_tm": "Palow",
+	}).expect(http.StatusConflict).decode(&body)
+	if body.Code != "CATEGORY_GONE" {
+		t.Errorf("code = %s, want CATEGORY_GONE", body.Code)
+	}
+}
+
+func TestDeleteCategoryInUse(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+	drinks := e.createCategory(cafe.ID, "Drinks", nil)
+	lunch := e.createFood(cafe.ID, mains.ID, "Lunch", 6000)
+	promotion := model.Promotion{
+		CafeID: cafe.ID, NameTm: "Drinks day", Type: model.PromotionPercent, Value: 1000, IsActive: true,
+		Targets: []model.PromotionTarget{{CategoryID: &drinks.ID, Quantity: 1}},
+	}
+	slot := model.ComboSlot{
+		ComboID: lunch.ID, NameTm: "Drink", Quantity: 1,
+		Options: []model.ComboSlotOption{{CategoryID: &drinks.ID}},
+	}
+	for _, record := range []any{&promotion, &slot} {
+		if err := e.db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	remove := fmt.Sprintf("/cafe/cafe/category/delete/%d", drinks.ID)
+	var body errorBody
+	res := e.do(http.MethodDelete, remove, token, "", nil).expect(http.StatusConflict)
+	res.decode(&body)
+	if body.Code != "CATEGORY_IN_USE" {
+		t.Errorf("code = %s, want CATEGORY_IN_USE", body.Code)
+	}
+	var conflict struct {
+		Promotions int64 `json:"promotions"`
+		Combos     int64 `json:"combos"`
+	}
+	res.data(&conflict)
+	if conflict.Promotions != 1 || conflict.Combos != 1 {
+		t.Errorf("conflict = %+v, want one promotion and one combo", conflict)
+	}
+
+	if err := e.db.Delete(&promotion).Error; err != nil {
+		t.Fatal(err)
+	}
+	e.do(http.MethodDelete, fmt.Sprintf("/cafe/foods/delete/%d", lunch.ID), token, "", nil).expect(http.StatusOK)
+	e.do(http.MethodDelete, remove, token, "", nil).expect(http.StatusOK)
+}
+
+func TestDeleteCafeCascades(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+	plov := e.createFood(cafe.ID, mains.ID, "Plov", 4500)
+	lunch := e.createFood(cafe.ID, mains.ID, "Lunch", 6000)
+	tag := model.Tag{CafeID: &cafe.ID, Code: "house", NameTM: "House"}
+	records := []any{
+		&tag,
+		&model.MenuSchedule{CafeID: cafe.ID, FoodID: &plov.ID, Weekday: 1, StartTime: "08:00", EndTime: "11:00"},
+		&model.ModifierGroup{FoodID: plov.ID, NameTm: "Size", Options: []model.ModifierOption{{NameTm: "Big"}}},
+		&model.ComboSlot{ComboID: lunch.ID, NameTm: "Main", Quantity: 1, Options: []model.ComboSlotOption{{FoodID: &plov.ID}}},
+	}
+	for _, record := range records {
+		if err := e.db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.db.Model(&plov).Association("Tags").Append(&tag); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.db.Unscoped().Delete(&cafe).Error; err != nil {
+		t.Fatalf("deleting the cafe: %v", err)
+	}
+	var left int64
+	if err := e.db.Unscoped().Model(&model.ModifierOption{}).Count(&left).Error; err != nil {
+		t.Fatal(err)
+	}
+	if left != 0 {
+		t.Errorf("%d modifier options outlived their cafe", left)
+	}
+}
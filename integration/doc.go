@@ -0,0 +1,9 @@
+// Package integration holds end-to-end tests that drive the HTTP API built by
+// route.CafeRoutes against a throwaway PostgreSQL database.
+//
+// The database comes from TEST_DATABASE_DSN, in which case the tests run in a
+// fresh schema that is dropped afterwards, or from a temporary cluster started
+// with the initdb and pg_ctl binaries found on PATH, under
+// /usr/lib/postgresql/*/bin or in POSTGRES_BIN_DIR. Without either the tests
+// are skipped.
+package integration
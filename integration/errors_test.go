@@ -0,0 +1,72 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type errorBody struct {
+	Success   bool   `json:"success"`
+	Code      string `json:"code"`
+	Error     string `json:"error"`
+	RequestID string `json:"request_id"`
+	Details   []struct {
+		Field   string `json:"field"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"details"`
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+
+	var body errorBody
+	e.get("/cafe/foods/999", "").expect(http.StatusNotFound).decode(&body)
+	if body.Success || body.Code != "FOOD_NOT_FOUND" || body.Error != "Food not found" || body.RequestID == "" {
+		t.Errorf("not found = %+v", body)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/cafe/foods/999", nil)
+	req.Header.Set("Accept-Language", "ru-RU,ru;q=0.9,en;q=0.8")
+	rec := httptest.NewRecorder()
+	e.router.ServeHTTP(rec, req)
+	res := &response{t, rec}
+	res.expect(http.StatusNotFound)
+	body = errorBody{}
+	res.decode(&body)
+	if body.Code != "FOOD_NOT_FOUND" || body.Error != "Блюдо не найдено" {
+		t.Errorf("russian not found = %+v", body)
+	}
+	if got := rec.Header().Get("Content-Language"); got != "ru" {
+		t.Errorf("Content-Language = %q, want ru", got)
+	}
+
+	body = errorBody{}
+	e.multipart(http.MethodPost, "/cafe/foods/add", token, url.Values{
+		"category_id": {fmt.Sprint(mains.ID)}, "price": {"abc"}, "name_tm": {"Palow"},
+	}, nil).expect(http.StatusBadRequest).decode(&body)
+	if body.Code != "VALIDATION_FAILED" || len(body.Details) != 1 ||
+		body.Details[0].Field != "price" || body.Details[0].Code != "INVALID_AMOUNT" {
+		t.Errorf("validation = %+v", body)
+	}
+
+	body = errorBody{}
+	e.form(http.MethodPost, "/cafe/auth/login", "", url.Values{"login": {"plov"}}).
+		expect(http.StatusBadRequest).decode(&body)
+	if len(body.Details) != 1 || body.Details[0].Field != "password" || body.Details[0].Code != "REQUIRED" {
+		t.Errorf("binding = %+v", body)
+	}
+
+	body = errorBody{}
+	e.form(http.MethodPost, "/cafe/auth/login", "", url.Values{"login": {"plov"}, "password": {"wrong"}}).
+		expect(http.StatusUnauthorized).decode(&body)
+	if body.Code != "INVALID_CREDENTIALS" {
+		t.Errorf("login = %+v", body)
+	}
+}
@@ -0,0 +1,399 @@
+package integration
+
+import (
+	"bytes"
+	"cafe/model"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestFoodCRUD(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+	soups := e.createCategory(cafe.ID, "Soups", nil)
+
+	e.multipart(http.MethodPost, "/cafe/foods/add", token, url.Values{
+		"category_id": {fmt.Sprint(mains.ID)}, "price": {"abc"}, "name_tm": {"Palow"},
+	}, nil).expect(http.StatusBadRequest)
+
+	res := e.multipart(http.MethodPost, "/cafe/foods/add", token, url.Values{
+		"category_id": {fmt.Sprint(mains.ID)}, "price": {"45.50"},
+		"name_tm": {"Palow"}, "name_ru": {"Плов"},
+	}, map[string]upload{"image": {"plov.jpg", []byte("\xff\xd8\xff")}}).expect(http.StatusOK)
+	var food model.Food
+	res.data(&food)
+	if food.Price != 4550 {
+		t.Errorf("price = %v, want 45.50", food.Price)
+	}
+	if food.Image == "" {
+		t.Error("image was not stored")
+	}
+
+	res = e.multipart(http.MethodPut, fmt.Sprintf("/cafe/foods/update/%d", food.ID), token, url.Values{
+		"price": {"50"}, "category_id": {fmt.Sprint(soups.ID)},
+	}, nil).expect(http.StatusOK)
+	res.data(&food)
+	if food.Price != 5000 || food.CategoryID != soups.ID {
+		t.Errorf("updated food = price %v category %d, want 50.00 in %d", food.Price, food.CategoryID, soups.ID)
+	}
+
+	var mine []model.Food
+	e.get("/cafe/foods/get-my", token).expect(http.StatusOK).data(&mine)
+	if len(mine) != 1 || mine[0].ID != food.ID {
+		t.Errorf("get-my returned %+v", mine)
+	}
+
+	e.do(http.MethodDelete, fmt.Sprintf("/cafe/foods/delete/%d", food.ID), token, "", nil).expect(http.StatusOK)
+	e.get(fmt.Sprintf("/cafe/foods/%d", food.ID), "").expect(http.StatusNotFound)
+}
+
+func TestFoodJSON(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+
+	e.json(http.MethodPost, "/cafe/foods/add", token, map[string]any{
+		"category_id": mains.ID, "price": 45.5, "name_tm": "Palow", "calories": -1,
+	}).expect(http.StatusBadRequest)
+
+	res := e.json(http.MethodPost, "/cafe/foods/add", token, map[string]any{
+		"category_id": mains.ID, "price": "45.50", "name_tm": "Palow", "spicy_level": 2,
+	}).expect(http.StatusOK)
+	var food model.Food
+	res.data(&food)
+	if food.Price != 4550 || food.SpicyLevel != 2 || food.Image != "" {
+		t.Errorf("added food = %+v", food)
+	}
+
+	e.json(http.MethodPut, fmt.Sprintf("/cafe/foods/update/%d", food.ID), token, map[string]any{
+		"price": 50, "name_ru": "Плов",
+	}).expect(http.StatusOK).data(&food)
+	if food.Price != 5000 || food.NameTm != "Palow" || food.NameRu != "Плов" {
+		t.Errorf("updated food = %+v", food)
+	}
+
+	imagePath := fmt.Sprintf("/cafe/foods/%d/image", food.ID)
+	e.multipart(http.MethodPut, imagePath, token, nil, nil).expect(http.StatusBadRequest)
+	e.multipart(http.MethodPut, imagePath, token, nil, map[string]upload{
+		"image": {"plov.gif", []byte("GIF89a")},
+	}).expect(http.StatusBadRequest)
+
+	e.multipart(http.MethodPut, imagePath, token, nil, map[string]upload{
+		"image": {"plov.jpg", []byte("\xff\xd8\xff")},
+	}).expect(http.StatusOK).data(&food)
+	first := food.Image
+	e.multipart(http.MethodPut, imagePath, token, nil, map[string]upload{
+		"image": {"plov.png", []byte("\x89PNG")},
+	}).expect(http.StatusOK).data(&food)
+	if first == "" || food.Image == first {
+		t.Fatalf("images = %q then %q", first, food.Image)
+	}
+	if _, err := os.Stat(filepath.Join(e.uploadDir(), first)); !os.IsNotExist(err) {
+		t.Errorf("replaced image was not deleted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(e.uploadDir(), food.Image)); err != nil {
+		t.Errorf("new image: %v", err)
+	}
+
+	second := food.Image
+	e.multipart(http.MethodPut, imagePath, token, nil, map[string]upload{
+		"image": {"again.png", []byte("\x89PNG")},
+	}).expect(http.StatusOK).data(&food)
+	if food.Image != second {
+		t.Errorf("the same image was stored as %q and %q", second, food.Image)
+	}
+	if _, err := os.Stat(filepath.Join(e.uploadDir(), food.Image)); err != nil {
+		t.Errorf("image uploaded again: %v", err)
+	}
+}
+
+func TestFoodPatch(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+
+	res := e.multipart(http.MethodPost, "/cafe/foods/add", token, url.Values{
+		"category_id": {fmt.Sprint(mains.ID)}, "price": {"45"}, "name_tm": {"Palow"}, "name_ru": {"Плов"},
+		"description_ru": {"С бараниной"}, "calories": {"700"},
+	}, map[string]upload{"image": {"plov.jpg", []byte("\xff\xd8\xff")}}).expect(http.StatusOK)
+	var food model.Food
+	res.data(&food)
+	path := fmt.Sprintf("/cafe/foods/update/%d", food.ID)
+
+	patch := func(body string) *response {
+		return e.do(http.MethodPatch, path, token, "application/merge-patch+json", strings.NewReader(body))
+	}
+	patch(`{"price": null}`).expect(http.StatusBadRequest)
+	patch(`{"name_tm": null, "name_ru": null}`).expect(http.StatusBadRequest)
+
+	patch(`{"description_ru": null, "calories": null, "name_tm": null}`).expect(http.StatusOK).data(&food)
+	if food.DescriptionRu != "" || food.Calories != nil || food.NameTm != "" || food.NameRu != "Плов" || food.Price != 4500 {
+		t.Errorf("patched food = %+v", food)
+	}
+
+	image := food.Image
+	e.do(http.MethodDelete, fmt.Sprintf("/cafe/foods/%d/image", food.ID), token, "", nil).expect(http.StatusOK)
+	var stored model.Food
+	if err := e.db.First(&stored, food.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Image != "" || stored.DescriptionRu != "" {
+		t.Errorf("stored food = %+v", stored)
+	}
+	if _, err := os.Stat(filepath.Join(e.uploadDir(), image)); !os.IsNotExist(err) {
+		t.Errorf("deleted image is still stored: %v", err)
+	}
+}
+
+func TestFoodVersion(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+	food := e.createFood(cafe.ID, mains.ID, "Palow", 4500)
+
+	ifMatch := func(method, path, tag, body string) *response {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("If-Match", tag)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		rec := httptest.NewRecorder()
+		e.router.ServeHTTP(rec, req)
+		return &response{t, rec}
+	}
+	update := fmt.Sprintf("/cafe/foods/update/%d", food.ID)
+
+	var read model.Food
+	res := e.do(http.MethodGet, fmt.Sprintf("/cafe/foods/get-my/%d", food.ID), token, "", nil).expect(http.StatusOK)
+	res.data(&read)
+	if tag := res.Header().Get("ETag"); tag != `"1"` || read.Version != 1 {
+		t.Fatalf("ETag = %s, version = %d, want 1", tag, read.Version)
+	}
+
+	res = ifMatch(http.MethodPut, update, `"1"`, `{"price": 50}`).expect(http.StatusOK)
+	var updated model.Food
+	res.data(&updated)
+	if tag := res.Header().Get("ETag"); tag != `"2"` || updated.Version != 2 {
+		t.Errorf("ETag = %s, version = %d after the update", tag, updated.Version)
+	}
+
+	var body errorBody
+	res = ifMatch(http.MethodPut, update, `"1"`, `{"price": 60}`).expect(http.StatusPreconditionFailed)
+	res.decode(&body)
+	if body.Code != "VERSION_MISMATCH" || res.Header().Get("ETag") != `"2"` {
+		t.Errorf("stale update = %+v, ETag %s", body, res.Header().Get("ETag"))
+	}
+	ifMatch(http.MethodPatch, update, "*", `{"sort_order": 3}`).expect(http.StatusOK)
+
+	e.form(http.MethodPut, fmt.Sprintf("/cafe/foods/availability/%d", food.ID), token, url.Values{
+		"is_available": {"false"},
+	}).expect(http.StatusOK).data(&updated)
+	if updated.Version != 4 {
+		t.Errorf("version after the stop-list toggle = %d, want 4", updated.Version)
+	}
+
+	tags := fmt.Sprintf("/cafe/foods/tags/%d", food.ID)
+	ifMatch(http.MethodPut, tags, `"3"`, `{"tag_ids": []}`).expect(http.StatusPreconditionFailed)
+	res = ifMatch(http.MethodPut, tags, `"4"`, `{"tag_ids": []}`).expect(http.StatusOK)
+	if tag := res.Header().Get("ETag"); tag != `"5"` {
+		t.Errorf("ETag after replacing the tags = %s, want \"5\"", tag)
+	}
+
+	remove := fmt.Sprintf("/cafe/foods/delete/%d", food.ID)
+	ifMatch(http.MethodDelete, remove, `"4"`, "").expect(http.StatusPreconditionFailed)
+	ifMatch(http.MethodDelete, remove, `"5"`, "").expect(http.StatusOK)
+
+	var stored model.Food
+	if err := e.db.First(&stored, food.ID).Error; err == nil {
+		t.Errorf("food %d was not deleted", food.ID)
+	}
+}
+
+func TestDeleteFoodInUse(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+	plov := e.createFood(cafe.ID, mains.ID, "Plov", 4500)
+	lunch := e.createFood(cafe.ID, mains.ID, "Lunch", 6000)
+	promotion := model.Promotion{
+		CafeID: cafe.ID, NameTm: "Plov day", Type: model.PromotionPercent, Value: 1000, IsActive: true,
+		Targets: []model.PromotionTarget{{FoodID: &plov.ID, Quantity: 1}},
+	}
+	slot := model.ComboSlot{
+		ComboID: lunch.ID, NameTm: "Main", Quantity: 1,
+		Options: []model.ComboSlotOption{{FoodID: &plov.ID}},
+	}
+	for _, record := range []any{&promotion, &slot} {
+		if err := e.db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	remove := fmt.Sprintf("/cafe/foods/delete/%d", plov.ID)
+	res := e.do(http.MethodDelete, remove, token, "", nil).expect(http.StatusConflict)
+	var conflict struct {
+		Promotions int64 `json:"promotions"`
+		Combos     int64 `json:"combos"`
+	}
+	res.data(&conflict)
+	if conflict.Promotions != 1 || conflict.Combos != 1 {
+		t.Errorf("conflict = %+v, want one promotion and one combo", conflict)
+	}
+
+	if err := e.db.Delete(&promotion).Error; err != nil {
+		t.Fatal(err)
+	}
+	e.do(http.MethodDelete, fmt.Sprintf("/cafe/foods/delete/%d", lunch.ID), token, "", nil).expect(http.StatusOK)
+	e.do(http.MethodDelete, remove, token, "", nil).expect(http.StatusOK)
+}
+
+func TestComboOptionCannotBecomeCombo(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+	plov := e.createFood(cafe.ID, mains.ID, "Plov", 4500)
+	soup := e.createFood(cafe.ID, mains.ID, "Soup", 2000)
+	lunch := e.createFood(cafe.ID, mains.ID, "Lunch", 6000)
+	e.json(http.MethodPut, fmt.Sprintf("/cafe/foods/combo/%d", lunch.ID), token, map[string]any{
+		"slots": []map[string]any{{"name_tm": "Main", "options": []map[string]any{{"food_id": plov.ID}}}},
+	}).expect(http.StatusOK)
+
+	var body errorBody
+	e.json(http.MethodPut, fmt.Sprintf("/cafe/foods/combo/%d", plov.ID), token, map[string]any{
+		"slots": []map[string]any{{"name_tm": "Soup", "options": []map[string]any{{"food_id": soup.ID}}}},
+	}).expect(http.StatusConflict).decode(&body)
+	if body.Code != "FOOD_IN_COMBO" {
+		t.Errorf("code = %s, want FOOD_IN_COMBO", body.Code)
+	}
+	if err := e.db.First(&plov, plov.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if plov.Kind != model.FoodSingle {
+		t.Errorf("kind = %s, want single", plov.Kind)
+	}
+}
+
+func TestFoodOwnership(t *testing.T) {
+	e := newEnv(t)
+	owner := e.createCafe("plov", "secret")
+	other := e.createCafe("somsa", "secret")
+	token := e.login("somsa", "secret")
+
+	theirs := e.createCategory(owner.ID, "Mains", nil)
+	food := e.createFood(owner.ID, theirs.ID, "Plov", 4500)
+	ours := e.createCategory(other.ID, "Pastry", nil)
+	own := e.createFood(other.ID, ours.ID, "Somsa", 1200)
+
+	e.multipart(http.MethodPut, fmt.Sprintf("/cafe/foods/update/%d", food.ID), token, url.Values{
+		"price": {"1"},
+	}, nil).expect(http.StatusForbidden)
+	e.multipart(http.MethodPut, fmt.Sprintf("/cafe/foods/%d/image", food.ID), token, nil, map[string]upload{
+		"image": {"somsa.jpg", []byte("\xff\xd8\xff")},
+	}).expect(http.StatusForbidden)
+	e.do(http.MethodDelete, fmt.Sprintf("/cafe/foods/%d/image", food.ID), token, "", nil).expect(http.StatusForbidden)
+	e.do(http.MethodDelete, fmt.Sprintf("/cafe/foods/delete/%d", food.ID), token, "", nil).expect(http.StatusForbidden)
+
+	e.multipart(http.MethodPost, "/cafe/foods/add", token, url.Values{
+		"category_id": {fmt.Sprint(theirs.ID)}, "price": {"10"}, "name_tm": {"Somsa"},
+	}, nil).expect(http.StatusBadRequest)
+	e.multipart(http.MethodPut, fmt.Sprintf("/cafe/foods/update/%d", own.ID), token, url.Values{
+		"category_id": {fmt.Sprint(theirs.ID)},
+	}, nil).expect(http.StatusBadRequest)
+
+	var unchanged model.Food
+	if err := e.db.First(&unchanged, food.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if unchanged.Price != 4500 {
+		t.Errorf("price of another cafe's food changed to %v", unchanged.Price)
+	}
+}
+
+func TestBulkAddFood(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	other := e.createCafe("somsa", "secret")
+	token := e.login("plov", "secret")
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+	theirs := e.createCategory(other.ID, "Pastry", nil)
+	existing := e.createFood(cafe.ID, mains.ID, "Manty", 3000)
+	if err := e.db.Model(&existing).Update("sort_order", 3).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	workbook := excelWorkbook(t, [][]any{
+		{"category_id", "price", "name_tm", "name_ru", "description_tm", "description_ru"},
+		{mains.ID, "45.5", "Palow", "Плов", "Dogralan et bilen", ""},
+		{mains.ID, "12", "Çorba", "Суп"},
+		{theirs.ID, "10", "Somsa", "Самса"},
+		{mains.ID, "free", "Çaý", "Чай"},
+		{mains.ID, "5"},
+	})
+
+	res := e.multipart(http.MethodPost, "/cafe/foods/add/excel", token, nil, map[string]upload{
+		"file": {"menu.xlsx", workbook},
+	}).expect(http.StatusOK)
+	var result struct {
+		Count int `json:"count"`
+	}
+	res.decode(&result)
+	if result.Count != 2 {
+		t.Errorf("count = %d, want 2", result.Count)
+	}
+
+	var foods []model.Food
+	if err := e.db.Where("id <> ?", existing.ID).Order("id").Find(&foods).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(foods) != 2 {
+		t.Fatalf("stored %d foods, want 2", len(foods))
+	}
+	if foods[0].Price != 4550 || foods[0].CafeID != cafe.ID || foods[0].DescriptionRu != "-" {
+		t.Errorf("first food = %+v", foods[0])
+	}
+	if foods[0].SortOrder != 4 || foods[1].SortOrder != 5 {
+		t.Errorf("sort orders = %d, %d; want 4, 5 after the existing food", foods[0].SortOrder, foods[1].SortOrder)
+	}
+
+	e.multipart(http.MethodPost, "/cafe/foods/add/excel", token, nil, map[string]upload{
+		"file": {"menu.xlsx", excelWorkbook(t, [][]any{{"category_id"}, {theirs.ID, "10", "Somsa", "Самса"}})},
+	}).expect(http.StatusBadRequest)
+}
+
+func excelWorkbook(t *testing.T, rows [][]any) []byte {
+	t.Helper()
+	file := excelize.NewFile()
+	defer file.Close()
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := file.SetSheetRow("Sheet1", cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := file.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,55 @@
+package integration
+
+import (
+	"cafe/config"
+	"cafe/logging"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestProbes(t *testing.T) {
+	e := newEnv(t)
+
+	res := e.get("/healthz", "").expect(http.StatusOK)
+	if res.Header().Get(logging.RequestIDHeader) == "" {
+		t.Error("response has no request ID")
+	}
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set(logging.RequestIDHeader, "from-proxy")
+	rec := httptest.NewRecorder()
+	e.router.ServeHTTP(rec, req)
+	if got := rec.Header().Get(logging.RequestIDHeader); got != "from-proxy" {
+		t.Errorf("request ID = %q, want the one sent by the proxy", got)
+	}
+	e.get("/readyz", "").expect(http.StatusOK)
+
+	config.Current.UploadDir = "/nonexistent/uploads"
+	res = e.get("/readyz", "").expect(http.StatusServiceUnavailable)
+	var body struct {
+		Checks map[string]string `json:"checks"`
+	}
+	res.decode(&body)
+	if body.Checks["database"] != "ok" || body.Checks["uploads"] == "ok" {
+		t.Errorf("checks = %v, want only uploads to fail", body.Checks)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	e := newEnv(t)
+	e.createCafe("plov", "secret")
+	e.login("plov", "secret")
+	e.form(http.MethodPost, "/cafe/auth/login", "", url.Values{"login": {"plov"}, "password": {"wrong"}})
+
+	res := e.get("/metrics", "").expect(http.StatusOK)
+	for _, want := range []string{
+		`cafe_logins_total{result="success"}`,
+		`cafe_logins_total{result="failure"}`,
+	} {
+		if !strings.Contains(res.Body.String(), want) {
+			t.Errorf("metrics are missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package integration
+
+import (
+	"bytes"
+	"cafe/config"
+	"cafe/database"
+	"cafe/logging"
+	"cafe/model"
+	"cafe/route"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	testDB     *gorm.DB
+	skipReason string
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logging.Setup(io.Discard, "info", "json")
+
+	dsn, stop, err := startPostgres()
+	if err == nil {
+		testDB, err = openTestDB(dsn)
+	}
+	if err != nil {
+		skipReason = err.Error()
+	}
+
+	code := m.Run()
+
+	if testDB != nil {
+		database.Close(testDB)
+	}
+	if stop != nil {
+		stop()
+	}
+	os.Exit(code)
+}
+
+func openTestDB(dsn string) (*gorm.DB, error) {
+	db, err := database.Connect(dsn)
+	if err != nil {
+		return nil, err
+	}
+	db = db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+	if _, err := database.MigrateUp(db); err != nil {
+		database.Close(db)
+		return nil, fmt.Errorf("migrating test database: %w", err)
+	}
+	return db, nil
+}
+
+// env is one test's view of the API: an empty database, its own upload
+// directory and a router wired the way serve wires it.
+type env struct {
+	t      *testing.T
+	db     *gorm.DB
+	router *gin.Engine
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	if testDB == nil {
+		t.Skipf("no test database: %s", skipReason)
+	}
+	resetDatabase(t)
+
+	previous := config.Current
+	t.Cleanup(func() { config.Current = previous })
+	config.Current = config.Default()
+	config.Current.Mode = gin.TestMode
+	config.Current.JWTSecret = "integration-test-secret-integration-test-secret"
+	config.Current.UploadDir = t.TempDir()
+
+	router := gin.New()
+	router.MaxMultipartMemory = config.Current.MaxMultipartBytes()
+	router.Use(logging.Middleware(), logging.Recovery())
+	route.SystemRoutes(router, testDB)
+	route.CafeRoutes(router, testDB)
+	route.UploadRoutes(router)
+	return &env{t: t, db: testDB, router: router}
+}
+
+// resetDatabase empties every table but the migration history and seeds the
+// predefined tags again.
+func resetDatabase(t *testing.T) {
+	t.Helper()
+	var tables []string
+	err := testDB.Raw("SELECT tablename FROM pg_tables WHERE schemaname = CURRENT_SCHEMA() AND tablename <> 'schema_migrations'").
+		Scan(&tables).Error
+	if err != nil {
+		t.Fatalf("listing tables: %v", err)
+	}
+	if err := testDB.Exec("TRUNCATE " + strings.Join(tables, ", ") + " RESTART IDENTITY CASCADE").Error; err != nil {
+		t.Fatalf("truncating tables: %v", err)
+	}
+	if _, err := database.MigrateUp(testDB); err != nil {
+		t.Fatalf("seeding: %v", err)
+	}
+}
+
+func (e *env) createCafe(login, password string) model.Cafe {
+	e.t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	cafe := model.Cafe{
+		Login:      login,
+		Password:   string(hash),
+		Name:       login,
+		UserRole:   "cafe",
+		ExpiryDate: time.Now().AddDate(0, 1, 0),
+		Timezone:   model.DefaultTimezone,
+		Currency:   "TMT",
+	}
+	if err := e.db.Create(&cafe).Error; err != nil {
+		e.t.Fatalf("creating cafe: %v", err)
+	}
+	return cafe
+}
+
+func (e *env) createCategory(cafeID uint, name string, parentID *uint) model.FoodCategory {
+	e.t.Helper()
+	category := model.FoodCategory{CafeId: cafeID, NameTM: name, NameRU: name, NameEN: name, ParentID: parentID}
+	if err := e.db.Create(&category).Error; err != nil {
+		e.t.Fatalf("creating category: %v", err)
+	}
+	return category
+}
+
+func (e *env) createFood(cafeID, categoryID uint, name string, price model.Money) model.Food {
+	e.t.Helper()
+	food := model.Food{CafeID: cafeID, CategoryID: categoryID, NameTm: name, NameRu: name, Price: price}
+	if err := e.db.Create(&food).Error; err != nil {
+		e.t.Fatalf("creating food: %v", err)
+	}
+	return food
+}
+
+// login returns the access token of the cafe.
+func (e *env) login(login, password string) string {
+	e.t.Helper()
+	res := e.form(http.MethodPost, "/cafe/auth/login", "", url.Values{"login": {login}, "password": {password}})
+	res.expect(http.StatusOK)
+	var tokens struct {
+		AccessToken string `json:"access_token"`
+	}
+	res.decode(&tokens)
+	return tokens.AccessToken
+}
+
+type response struct {
+	t *testing.T
+	*httptest.ResponseRecorder
+}
+
+func (r *response) expect(status int) *response {
+	r.t.Helper()
+	if r.Code != status {
+		r.t.Fatalf("status = %d, want %d; body: %s", r.Code, status, r.Body.String())
+	}
+	return r
+}
+
+func (r *response) decode(v any) {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body.Bytes(), v); err != nil {
+		r.t.Fatalf("decoding %s: %v", r.Body.String(), err)
+	}
+}
+
+// data decodes the "data" field of the response envelope into v.
+func (r *response) data(v any) {
+	r.t.Helper()
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	r.decode(&envelope)
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		r.t.Fatalf("decoding data of %s: %v", r.Body.String(), err)
+	}
+}
+
+func (e *env) do(method, path, token, contentType string, body io.Reader) *response {
+	e.t.Helper()
+	req := httptest.NewRequest(method, path, body)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	e.router.ServeHTTP(rec, req)
+	return &response{t: e.t, ResponseRecorder: rec}
+}
+
+func (e *env) get(path, token string) *response {
+	e.t.Helper()
+	return e.do(http.MethodGet, path, token, "", nil)
+}
+
+func (e *env) form(method, path, token string, fields url.Values) *response {
+	e.t.Helper()
+	return e.do(method, path, token, "application/x-www-form-urlencoded", strings.NewReader(fields.Encode()))
+}
+
+func (e *env) json(method, path, token string, body any) *response {
+	e.t.Helper()
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	return e.do(method, path, token, "application/json", bytes.NewReader(encoded))
+}
+
+// multipart sends fields together with files, keyed by form field and given
+// as file name and content.
+func (e *env) multipart(method, path, token string, fields url.Values, files map[string]upload) *response {
+	e.t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, values := range fields {
+		for _, value := range values {
+			writer.WriteField(key, value)
+		}
+	}
+	for field, file := range files {
+		part, err := writer.CreateFormFile(field, file.name)
+		if err != nil {
+			e.t.Fatal(err)
+		}
+		part.Write(file.content)
+	}
+	if err := writer.Close(); err != nil {
+		e.t.Fatal(err)
+	}
+	return e.do(method, path, token, writer.FormDataContentType(), &body)
+}
+
+type upload struct {
+	name    string
+	content []byte
+}
+
+func (e *env) uploadDir() string {
+	return config.Current.UploadDir
+}
@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"cafe/model"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type menuCategory struct {
+	model.FoodCategory
+	Foods    []model.Food    `json:"foods"`
+	Children []*menuCategory `json:"children"`
+}
+
+func TestPublicMenu(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	other := e.createCafe("somsa", "secret")
+
+	drinks := e.createCategory(cafe.ID, "Drinks", nil)
+	hot := e.createCategory(cafe.ID, "Hot", &drinks.ID)
+	tea := e.createFood(cafe.ID, hot.ID, "Tea", 500)
+	e.createFood(cafe.ID, drinks.ID, "Water", 300)
+	e.createCategory(other.ID, "Pastry", nil)
+
+	var tree []menuCategory
+	e.get(fmt.Sprintf("/cafe/categories/foods?cafe_id=%d", cafe.ID), "").expect(http.StatusOK).data(&tree)
+	if len(tree) != 1 || tree[0].ID != drinks.ID {
+		t.Fatalf("top-level categories = %+v, want only Drinks", tree)
+	}
+	if len(tree[0].Foods) != 1 || len(tree[0].Children) != 1 {
+		t.Fatalf("Drinks has %d foods and %d children, want 1 and 1", len(tree[0].Foods), len(tree[0].Children))
+	}
+	if child := tree[0].Children[0]; child.ID != hot.ID || len(child.Foods) != 1 || child.Foods[0].ID != tea.ID {
+		t.Errorf("Hot = %+v", child)
+	}
+
+	var foods []model.Food
+	e.get(fmt.Sprintf("/cafe/foods/by-category?category_id=%d", hot.ID), "").expect(http.StatusOK).data(&foods)
+	if len(foods) != 1 || foods[0].ID != tea.ID {
+		t.Errorf("by-category = %+v, want only Tea", foods)
+	}
+	e.get("/cafe/foods/by-category", "").expect(http.StatusBadRequest)
+	e.get("/cafe/foods/by-category?category_id=9999", "").expect(http.StatusNotFound)
+
+	var food model.Food
+	e.get(fmt.Sprintf("/cafe/foods/%d", tea.ID), "").expect(http.StatusOK).data(&food)
+	if food.NameTm != "Tea" || food.Price != 500 {
+		t.Errorf("food = %+v", food)
+	}
+
+	var categories []model.FoodCategory
+	e.get(fmt.Sprintf("/cafe/categories//categories/%d", cafe.ID), "").expect(http.StatusOK).data(&categories)
+	if len(categories) != 2 {
+		t.Errorf("got %d categories, want 2", len(categories))
+	}
+
+	e.get("/cafe/categories/foods", "").expect(http.StatusBadRequest)
+	e.get("/cafe/categories/foods?cafe_id=9999", "").expect(http.StatusNotFound)
+}
+
+func TestMenuCaching(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+	food := e.createFood(cafe.ID, mains.ID, "Palow", 4500)
+	menu := fmt.Sprintf("/cafe/categories/foods?cafe_id=%d", cafe.ID)
+
+	conditional := func(path, header, value string) *response {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(header, value)
+		rec := httptest.NewRecorder()
+		e.router.ServeHTTP(rec, req)
+		return &response{t, rec}
+	}
+
+	res := e.get(menu, "").expect(http.StatusOK)
+	etag, modified := res.Header().Get("ETag"), res.Header().Get("Last-Modified")
+	if etag == "" || modified == "" || res.Header().Get("Cache-Control") != "public, max-age=60" {
+		t.Fatalf("caching headers = %v", res.Header())
+	}
+	if res := conditional(menu, "If-None-Match", etag).expect(http.StatusNotModified); res.Body.Len() != 0 {
+		t.Errorf("304 has a body: %s", res.Body.String())
+	}
+	conditional(menu, "If-Modified-Since", modified).expect(http.StatusNotModified)
+	conditional(fmt.Sprintf("/cafe/foods/%d", food.ID), "If-None-Match", etag).expect(http.StatusNotModified)
+	conditional("/cafe/foods/999", "If-None-Match", etag).expect(http.StatusNotFound)
+
+	e.form(http.MethodPut, fmt.Sprintf("/cafe/foods/availability/%d", food.ID), token, url.Values{
+		"is_available": {"false"},
+	}).expect(http.StatusOK)
+	res = conditional(menu, "If-None-Match", etag).expect(http.StatusOK)
+	if res.Header().Get("ETag") == etag {
+		t.Errorf("ETag %s did not change with the menu", etag)
+	}
+
+	var added model.Food
+	e.multipart(http.MethodPost, "/cafe/foods/add", token, url.Values{
+		"category_id": {fmt.Sprint(mains.ID)}, "price": {"12"}, "name_tm": {"Çaý"},
+	}, map[string]upload{"image": {"tea.jpg", []byte("\xff\xd8\xff")}}).expect(http.StatusOK).data(&added)
+	res = e.get("/uploads/"+added.Image, "").expect(http.StatusOK)
+	if got := res.Header().Get("Cache-Control"); got != "public, max-age=31536000, immutable" {
+		t.Errorf("upload Cache-Control = %q", got)
+	}
+	res = e.get("/uploads/missing.jpg", "").expect(http.StatusNotFound)
+	if got := res.Header().Get("Cache-Control"); got != "" {
+		t.Errorf("Cache-Control of a missing upload = %q", got)
+	}
+}
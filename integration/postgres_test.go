@@ -0,0 +1,122 @@
+package integration
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// startPostgres returns the DSN of an empty database and a function that
+// removes it again.
+func startPostgres() (string, func(), error) {
+	if dsn := os.Getenv("TEST_DATABASE_DSN"); dsn != "" {
+		return freshSchema(dsn)
+	}
+	return tempCluster()
+}
+
+// freshSchema creates a uniquely named schema in an existing database and
+// points the DSN at it through search_path.
+func freshSchema(dsn string) (string, func(), error) {
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return "", nil, err
+	}
+	schema := fmt.Sprintf("cafe_test_%d_%d", os.Getpid(), time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		admin.Close()
+		return "", nil, fmt.Errorf("creating test schema: %w", err)
+	}
+
+	stop := func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+	}
+	if strings.Contains(dsn, "://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		return dsn + separator + "search_path=" + schema, stop, nil
+	}
+	return dsn + " search_path=" + schema, stop, nil
+}
+
+// tempCluster initializes a new cluster in a temporary directory and starts
+// it on a free port, listening on a unix socket only.
+func tempCluster() (string, func(), error) {
+	binDir, err := postgresBinDir()
+	if err != nil {
+		return "", nil, err
+	}
+	if os.Geteuid() == 0 {
+		return "", nil, errors.New("initdb refuses to run as root; set TEST_DATABASE_DSN instead")
+	}
+
+	dir, err := os.MkdirTemp("", "cafe-pg-")
+	if err != nil {
+		return "", nil, err
+	}
+	dataDir := filepath.Join(dir, "data")
+
+	initdb := exec.Command(filepath.Join(binDir, "initdb"), "-D", dataDir, "-U", "postgres", "--auth=trust", "-E", "UTF8", "--no-sync")
+	if out, err := initdb.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("initdb: %v\n%s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	options := fmt.Sprintf("-p %d -k %s -c listen_addresses='' -c fsync=off", port, dir)
+	pgCtl := filepath.Join(binDir, "pg_ctl")
+	start := exec.Command(pgCtl, "-D", dataDir, "-o", options, "-l", filepath.Join(dir, "postgres.log"), "-w", "start")
+	if out, err := start.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("pg_ctl start: %v\n%s", err, out)
+	}
+
+	stop := func() {
+		exec.Command(pgCtl, "-D", dataDir, "-m", "immediate", "stop").Run()
+		os.RemoveAll(dir)
+	}
+	dsn := fmt.Sprintf("host=%s port=%d user=postgres dbname=postgres sslmode=disable", dir, port)
+	return dsn, stop, nil
+}
+
+func postgresBinDir() (string, error) {
+	if dir := os.Getenv("POSTGRES_BIN_DIR"); dir != "" {
+		return dir, nil
+	}
+	if path, err := exec.LookPath("initdb"); err == nil {
+		return filepath.Dir(path), nil
+	}
+	candidates, _ := filepath.Glob("/usr/lib/postgresql/*/bin")
+	sort.Strings(candidates)
+	for i := len(candidates) - 1; i >= 0; i-- {
+		if _, err := os.Stat(filepath.Join(candidates[i], "initdb")); err == nil {
+			return candidates[i], nil
+		}
+	}
+	return "", errors.New("no PostgreSQL binaries found; set TEST_DATABASE_DSN or POSTGRES_BIN_DIR")
+}
+
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"cafe/model"
+	"cafe/pricing"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyDueChanges(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("kebab", "secret1")
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+	food := e.createFood(cafe.ID, mains.ID, "Palow", 4550)
+
+	now := time.Now()
+	due := model.FoodPriceChange{
+		FoodID: food.ID, CafeID: cafe.ID, NewPrice: 5000,
+		Source: model.PriceChangeScheduled, Status: model.PriceChangePending, EffectiveAt: now.Add(-time.Minute),
+	}
+	cancelled := due
+	cancelled.NewPrice = 9900
+	cancelled.Status = model.PriceChangeCancelled
+	for _, change := range []*model.FoodPriceChange{&due, &cancelled} {
+		if err := e.db.Create(change).Error; err != nil {
+			t.Fatalf("creating price change: %v", err)
+		}
+	}
+
+	applied, err := pricing.ApplyDueChanges(e.db, now)
+	if err != nil || applied != 1 {
+		t.Fatalf("ApplyDueChanges = %d, %v; want 1, nil", applied, err)
+	}
+
+	if err := e.db.First(&due, due.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if due.Status != model.PriceChangeApplied || due.OldPrice != 4550 || due.NewPrice != 5000 {
+		t.Errorf("applied change = %s %v -> %v, want applied 45.50 -> 50.00", due.Status, due.OldPrice, due.NewPrice)
+	}
+	if err := e.db.First(&food, food.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if food.Price != 5000 {
+		t.Errorf("price = %v, want 50.00", food.Price)
+	}
+}
+
+func TestChangeFoodPriceVersion(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("kebab", "secret1")
+	token := e.login("kebab", "secret1")
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+	food := e.createFood(cafe.ID, mains.ID, "Palow", 4550)
+
+	change := func(tag, price string) *response {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/cafe/foods/%d/price-changes", food.ID), strings.NewReader("price="+price))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("If-Match", tag)
+		rec := httptest.NewRecorder()
+		e.router.ServeHTTP(rec, req)
+		return &response{t, rec}
+	}
+
+	res := change(`"1"`, "50").expect(http.StatusOK)
+	var changed model.Food
+	res.data(&changed)
+	if tag := res.Header().Get("ETag"); tag != `"2"` || changed.Version != 2 || changed.Price != 5000 {
+		t.Errorf("ETag = %s, food = %+v after the price change", tag, changed)
+	}
+
+	var body errorBody
+	change(`"1"`, "60").expect(http.StatusPreconditionFailed).decode(&body)
+	if body.Code != "VERSION_MISMATCH" {
+		t.Errorf("code = %s, want VERSION_MISMATCH", body.Code)
+	}
+	if err := e.db.First(&food, food.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if food.Price != 5000 {
+		t.Errorf("stale price change was applied, price = %v", food.Price)
+	}
+}
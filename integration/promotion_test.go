@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"cafe/model"
+	"cafe/pricing"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestInactivePromotion(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("plov", "secret")
+	token := e.login("plov", "secret")
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+	plov := e.createFood(cafe.ID, mains.ID, "Plov", 4500)
+
+	var promotion model.Promotion
+	e.json(http.MethodPost, "/cafe/promotions/add", token, map[string]any{
+		"name_tm": "Plov day", "type": "percent", "value": 20, "is_active": false,
+		"targets": []map[string]any{{"food_id": plov.ID}},
+	}).expect(http.StatusOK).data(&promotion)
+	if promotion.IsActive {
+		t.Fatalf("promotion posted as a draft was created active")
+	}
+	var stored model.Promotion
+	if err := e.db.First(&stored, promotion.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.IsActive {
+		t.Errorf("promotion posted as a draft was stored active")
+	}
+
+	var food model.Food
+	e.get(fmt.Sprintf("/cafe/foods/%d", plov.ID), "").expect(http.StatusOK).data(&food)
+	if food.DiscountedPrice != nil || food.PromotionID != nil {
+		t.Errorf("menu shows a draft promotion on %+v", food)
+	}
+
+	var quote pricing.Quote
+	e.json(http.MethodPost, "/cafe/orders/quote", "", map[string]any{
+		"cafe_id": cafe.ID, "items": []map[string]any{{"food_id": plov.ID, "quantity": 1}},
+	}).expect(http.StatusOK).data(&quote)
+	if quote.Discount != 0 || len(quote.Promotions) != 0 {
+		t.Errorf("quote applies a draft promotion: %+v", quote)
+	}
+}
+
+func TestNestedCategoryTargets(t *testing.T) {
+	e := newEnv(t)
+	cafe := e.createCafe("kofe", "secret")
+	token := e.login("kofe", "secret")
+	mains := e.createCategory(cafe.ID, "Mains", nil)
+	drinks := e.createCategory(cafe.ID, "Drinks", nil)
+	coffee := e.createCategory(cafe.ID, "Coffee", &drinks.ID)
+	latte := e.createFood(cafe.ID, coffee.ID, "Latte", 2000)
+	lunch := e.createFood(cafe.ID, mains.ID, "Lunch", 6000)
+
+	e.json(http.MethodPost, "/cafe/promotions/add", token, map[string]any{
+		"name_tm": "Drinks day", "type": "percent", "value": 10, "is_active": true,
+		"targets": []map[string]any{{"category_id": drinks.ID}},
+	}).expect(http.StatusOK)
+	e.json(http.MethodPut, fmt.Sprintf("/cafe/foods/combo/%d", lunch.ID), token, map[string]any{
+		"slots": []map[string]any{{"name_tm": "Drink", "options": []map[string]any{{"category_id": drinks.ID}}}},
+	}).expect(http.StatusOK)
+
+	var food model.Food
+	e.get(fmt.Sprintf("/cafe/foods/%d", latte.ID), "").expect(http.StatusOK).data(&food)
+	if food.DiscountedPrice == nil || *food.DiscountedPrice != 1800 {
+		t.Errorf("menu price of a food in a subcategory = %+v, want 18.00", food)
+	}
+
+	var quote pricing.Quote
+	e.json(http.MethodPost, "/cafe/orders/quote", "", map[string]any{
+		"cafe_id": cafe.ID, "items": []map[string]any{
+			{"food_id": latte.ID, "quantity": 1},
+			{"food_id": lunch.ID, "quantity": 1, "combo_choices": []map[string]any{{"slot_id": comboSlotID(e, lunch.ID), "food_id": latte.ID}}},
+		},
+	}).expect(http.StatusOK).data(&quote)
+	if quote.Discount != 200 {
+		t.Errorf("quote discount = %v, want 2.00 from the parent category promotion", quote.Discount)
+	}
+}
+
+func comboSlotID(e *env, comboID uint) uint {
+	e.t.Helper()
+	var slot model.ComboSlot
+	if err := e.db.Where("combo_id = ?", comboID).First(&slot).Error; err != nil {
+		e.t.Fatal(err)
+	}
+	return slot.ID
+}
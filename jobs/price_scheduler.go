@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"cafe/pricing"
+	"context"
+	"gorm.io/gorm"
+	"log/slog"
+	"time"
+)
+
+// StartPriceScheduler applies due scheduled price changes every interval
+// until ctx is cancelled. The returned channel is closed once the scheduler
+// has stopped, so a run in progress can finish before the pool is closed.
+func StartPriceScheduler(ctx context.Context, db *gorm.DB, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			applyDuePrices(db.WithContext(ctx))
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return done
+}
+
+func applyDuePrices(db *gorm.DB) {
+	applied, err := pricing.ApplyDueChanges(db, time.Now())
+	if err != nil {
+		slog.Error("Failed to apply scheduled price changes", "error", err)
+	}
+	if applied > 0 {
+		slog.Info("Applied scheduled price changes", "count", applied)
+	}
+}
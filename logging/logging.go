@@ -0,0 +1,107 @@
+// Package logging sets up the structured logger and carries per-request
+// fields such as the request ID and cafe ID through the request context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// ParseLevel accepts debug, info, warn or error.
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", value)
+	}
+	return level, nil
+}
+
+// Setup makes a JSON (or, for development, text) logger writing to w the
+// default for both slog and the standard log package.
+func Setup(w io.Writer, level, format string) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	options := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		handler = slog.NewJSONHandler(w, options)
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	return nil
+}
+
+// requestInfo is stored in the request context once and filled in as the
+// request passes through the middleware, so entries logged with that context
+// pick up the cafe ID even when it becomes known after the context was made.
+type requestInfo struct {
+	requestID string
+	route     string
+	cafeID    uint
+}
+
+type requestInfoKey struct{}
+
+func withRequestInfo(ctx context.Context, info *requestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+func infoFrom(ctx context.Context) *requestInfo {
+	if ctx == nil {
+		return nil
+	}
+	info, _ := ctx.Value(requestInfoKey{}).(*requestInfo)
+	return info
+}
+
+// RequestID returns the ID of the request ctx belongs to, if any.
+func RequestID(ctx context.Context) string {
+	if info := infoFrom(ctx); info != nil {
+		return info.requestID
+	}
+	return ""
+}
+
+// SetCafeID records the authenticated cafe for the rest of the request.
+func SetCafeID(ctx context.Context, cafeID uint) {
+	if info := infoFrom(ctx); info != nil {
+		info.cafeID = cafeID
+	}
+}
+
+// contextHandler adds the request fields to every entry logged with a
+// request context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if info := infoFrom(ctx); info != nil {
+		record.AddAttrs(slog.String("request_id", info.requestID))
+		if info.route != "" {
+			record.AddAttrs(slog.String("route", info.route))
+		}
+		if info.cafeID != 0 {
+			record.AddAttrs(slog.Uint64("cafe_id", uint64(info.cafeID)))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,126 @@
+// Package metrics collects the Prometheus metrics served on /metrics.
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "cafe"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	uploadSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upload_size_bytes",
+		Help:      "Size of uploaded files by kind.",
+		// 16KB up to 16MB.
+		Buckets: prometheus.ExponentialBuckets(16<<10, 4, 6),
+	}, []string{"kind"})
+
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by result.",
+	}, []string{"result"})
+
+	importRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "excel_import_rows_total",
+		Help:      "Rows of Excel food imports by outcome.",
+	}, []string{"outcome"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requests,
+		requestDuration,
+		uploadSize,
+		logins,
+		importRows,
+	)
+}
+
+// RegisterDB exposes the statistics of the connection pool.
+func RegisterDB(db *sql.DB) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// Handler serves the collected metrics in the Prometheus text format.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry}))
+}
+
+// Middleware counts requests and their latency. Routes are labelled by their
+// pattern, e.g. /cafe/foods/:id, so IDs do not create new series.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		requests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		requestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Upload kinds.
+const (
+	UploadLogo          = "logo"
+	UploadCategoryImage = "category_image"
+	UploadFoodImage     = "food_image"
+	UploadExcel         = "excel"
+)
+
+func ObserveUpload(kind string, size int64) {
+	uploadSize.WithLabelValues(kind).Observe(float64(size))
+}
+
+// Login results.
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+func CountLogin(result string) {
+	logins.WithLabelValues(result).Inc()
+}
+
+// Excel import row outcomes.
+const (
+	RowImported        = "imported"
+	RowIncomplete      = "incomplete"
+	RowInvalidPrice    = "invalid_price"
+	RowInvalidCategory = "invalid_category"
+	RowMissingName     = "missing_name"
+	RowFailed          = "failed"
+)
+
+func CountImportRows(outcome string, n int) {
+	importRows.WithLabelValues(outcome).Add(float64(n))
+}
@@ -15,6 +15,36 @@ type Cafe struct {
 	Code         string      `json:"code"`
 	PhoneNumbers []CafePhone `json:"phone_numbers" gorm:"foreignKey:CafeID"`
 	ExpiryDate   time.Time   `json:"expiry_date"`
+	// HideUnavailableFoods removes stop-listed foods from the public menu
+	// instead of returning them marked as unavailable.
+	HideUnavailableFoods bool   `json:"hide_unavailable_foods" gorm:"not null;default:false"`
+	Timezone             string `json:"timezone" gorm:"not null;default:'Asia/Ashgabat'"`
+	Currency             string `json:"currency" gorm:"size:3;not null;default:'TMT'"`
+	// Version counts the changes of the cafe; see BeforeUpdate.
+	Version uint `json:"version" gorm:"not null;default:1"`
+}
+
+const DefaultTimezone = "Asia/Ashgabat"
+
+// Location returns the cafe's timezone, falling back to DefaultTimezone.
+func (c *Cafe) Location() *time.Location {
+	if c.Timezone != "" {
+		if loc, err := time.LoadLocation(c.Timezone); err == nil {
+			return loc
+		}
+	}
+	loc, err := time.LoadLocation(DefaultTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// TouchCafe moves the UpdatedAt of a cafe to now. Public menus are cached
+// until it changes, so everything that changes the menu of a cafe touches it.
+// It skips the hooks, since the cafe itself stays the same version.
+func TouchCafe(db *gorm.DB, cafeID uint) error {
+	return db.Model(&Cafe{}).Where("id = ?", cafeID).UpdateColumn("updated_at", time.Now()).Error
 }
 
 type CafePhone struct {
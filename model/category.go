@@ -4,9 +4,29 @@ import "gorm.io/gorm"
 
 type FoodCategory struct {
 	gorm.Model
-	NameTM string `json:"name_tm"`
-	NameRU string `json:"name_ru"`
-	NameEN string `json:"name_en"`
-	Image  string `json:"image"`
-	CafeId uint   `json:"cafe_id"`
+	NameTM    string         `json:"name_tm"`
+	NameRU    string         `json:"name_ru"`
+	NameEN    string         `json:"name_en"`
+	Image     string         `json:"image"`
+	CafeId    uint           `json:"cafe_id"`
+	SortOrder int            `json:"sort_order" gorm:"default:0;index"`
+	ParentID  *uint          `json:"parent_id" gorm:"index"`
+	Schedules []MenuSchedule `json:"schedules,omitempty" gorm:"foreignKey:CategoryID"`
+	// Version counts the changes of the category; see BeforeUpdate.
+	Version uint `json:"version" gorm:"not null;default:1"`
+}
+
+// MaxCategoryDepth limits nesting, e.g. Drinks → Hot → Coffee.
+const MaxCategoryDepth = 3
+
+// CategorySet holds the IDs of a category and all of its subcategories, so
+// that a promotion target or combo option naming a category also covers the
+// foods of nested categories. A nil set covers the category alone.
+type CategorySet map[uint]bool
+
+func (s CategorySet) covers(root, categoryID uint) bool {
+	if s == nil {
+		return root == categoryID
+	}
+	return s[categoryID]
 }
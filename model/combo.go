@@ -0,0 +1,120 @@
+package model
+
+import "gorm.io/gorm"
+
+type FoodKind string
+
+const (
+	FoodSingle FoodKind = "single"
+	FoodCombo  FoodKind = "combo"
+)
+
+// ComboSlot is one part of a combo food, such as the soup or the drink of a
+// business lunch. Guests fill it with Quantity foods picked from its options.
+type ComboSlot struct {
+	gorm.Model
+	ComboID   uint              `json:"combo_id" gorm:"index"`
+	NameTm    string            `json:"name_tm"`
+	NameRu    string            `json:"name_ru"`
+	Quantity  int               `json:"quantity" gorm:"not null;default:1"`
+	Optional  bool              `json:"optional"`
+	SortOrder int               `json:"sort_order" gorm:"default:0"`
+	Options   []ComboSlotOption `json:"options" gorm:"foreignKey:SlotID"`
+}
+
+// ComboSlotOption offers a single food, or every food of a category, for a
+// slot. PriceDelta is charged on top of the combo price when it is picked.
+type ComboSlotOption struct {
+	ID         uint          `json:"id" gorm:"primarykey"`
+	SlotID     uint          `json:"slot_id" gorm:"index"`
+	FoodID     *uint         `json:"food_id"`
+	CategoryID *uint         `json:"category_id"`
+	PriceDelta Money         `json:"price_delta"`
+	Food       *Food         `json:"food,omitempty" gorm:"foreignKey:FoodID"`
+	Category   *FoodCategory `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	// Subtree holds CategoryID and its subcategories once they are resolved,
+	// see CategorySet.
+	Subtree CategorySet `json:"-" gorm:"-"`
+}
+
+func (s *ComboSlot) Validate() error {
+	if s.NameTm == "" && s.NameRu == "" {
+		return invalid("name_tm", "NAME_REQUIRED", "at least one combo slot name (TM or RU) is required")
+	}
+	if s.Quantity < 1 {
+		return invalid("quantity", "MIN_VALUE", "combo slot quantity must be at least %d", 1)
+	}
+	if len(s.Options) == 0 {
+		return invalid("options", "EMPTY_LIST", "a combo slot needs at least one option")
+	}
+	for _, option := range s.Options {
+		if (option.FoodID == nil) == (option.CategoryID == nil) {
+			return invalid("options", "TARGET_REQUIRED", "every combo option needs either a food_id or a category_id")
+		}
+		if option.PriceDelta < 0 {
+			return invalid("price_delta", "NEGATIVE", "combo option price_delta must not be negative")
+		}
+	}
+	return nil
+}
+
+// option returns the slot option offering food, if any. A food option wins
+// over a category option so that its own surcharge applies.
+func (s *ComboSlot) option(food *Food) *ComboSlotOption {
+	var byCategory *ComboSlotOption
+	for i := range s.Options {
+		option := &s.Options[i]
+		if option.FoodID != nil && *option.FoodID == food.ID {
+			return option
+		}
+		if option.CategoryID != nil && option.Subtree.covers(*option.CategoryID, food.CategoryID) && byCategory == nil {
+			byCategory = option
+		}
+	}
+	return byCategory
+}
+
+// ComboSelectionPrice checks the foods picked for every slot of a combo and
+// returns the total surcharge of the picks. ComboSlots and their options
+// must be loaded.
+func (f *Food) ComboSelectionPrice(picks map[uint][]*Food) (Money, error) {
+	var total Money
+	for slotID := range picks {
+		found := false
+		for _, slot := range f.ComboSlots {
+			found = found || slot.ID == slotID
+		}
+		if !found {
+			return 0, invalid("combo_choices", "UNKNOWN_COMBO_SLOT", "combo slot %d does not belong to this food", slotID)
+		}
+	}
+
+	for i := range f.ComboSlots {
+		slot := &f.ComboSlots[i]
+		picked := picks[slot.ID]
+		if len(picked) == 0 && slot.Optional {
+			continue
+		}
+		if len(picked) != slot.Quantity {
+			return 0, invalid("combo_choices", "COMBO_PICK_COUNT", "pick %d item(s) for %q", slot.Quantity, slot.displayName())
+		}
+		for _, food := range picked {
+			if food.Kind == FoodCombo {
+				return 0, invalid("combo_choices", "NESTED_COMBO", "a combo cannot contain another combo")
+			}
+			option := slot.option(food)
+			if option == nil {
+				return 0, invalid("combo_choices", "INVALID_COMBO_PICK", "food %d cannot be picked for %q", food.ID, slot.displayName())
+			}
+			total += option.PriceDelta
+		}
+	}
+	return total, nil
+}
+
+func (s *ComboSlot) displayName() string {
+	if s.NameTm != "" {
+		return s.NameTm
+	}
+	return s.NameRu
+}
@@ -1,15 +1,45 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"gorm.io/gorm"
+	"time"
+)
 
 type Food struct {
 	gorm.Model
-	CafeID        uint    `json:"cafe_id"`
-	CategoryID    uint    `json:"category_id"`
-	Image         string  `json:"image"`
-	Price         float64 `json:"price"`
-	NameTm        string  `json:"name_tm"`
-	NameRu        string  `json:"name_ru"`
-	DescriptionTm string  `json:"description_tm"`
-	DescriptionRu string  `json:"description_ru"`
+	CafeID           uint            `json:"cafe_id"`
+	CategoryID       uint            `json:"category_id"`
+	Image            string          `json:"image"`
+	Price            Money           `json:"price"`
+	NameTm           string          `json:"name_tm"`
+	NameRu           string          `json:"name_ru"`
+	DescriptionTm    string          `json:"description_tm"`
+	DescriptionRu    string          `json:"description_ru"`
+	SortOrder        int             `json:"sort_order" gorm:"default:0;index"`
+	IsAvailable      bool            `json:"is_available" gorm:"not null;default:true"`
+	AvailableAgainAt *time.Time      `json:"available_again_at"`
+	Schedules        []MenuSchedule  `json:"schedules,omitempty" gorm:"foreignKey:FoodID"`
+	ModifierGroups   []ModifierGroup `json:"modifier_groups,omitempty" gorm:"foreignKey:FoodID"`
+	// SpicyLevel goes from 0 (not spicy) to MaxSpicyLevel.
+	SpicyLevel      int         `json:"spicy_level" gorm:"not null;default:0"`
+	Calories        *int        `json:"calories"`
+	WeightGrams     *int        `json:"weight_grams"`
+	VolumeMl        *int        `json:"volume_ml"`
+	PrepTimeMinutes *int        `json:"prep_time_minutes"`
+	Tags            []Tag       `json:"tags,omitempty" gorm:"many2many:food_tags"`
+	Kind            FoodKind    `json:"kind" gorm:"size:16;not null;default:'single'"`
+	ComboSlots      []ComboSlot `json:"combo_slots,omitempty" gorm:"foreignKey:ComboID"`
+	// DiscountedPrice is filled in for public menus while a promotion applies.
+	DiscountedPrice *Money `json:"discounted_price,omitempty" gorm:"-"`
+	PromotionID     *uint  `json:"promotion_id,omitempty" gorm:"-"`
+	// Version counts the changes of the food; see BeforeUpdate.
+	Version uint `json:"version" gorm:"not null;default:1"`
+}
+
+const MaxSpicyLevel = 3
+
+// Available reports whether the food can be served at the given moment,
+// taking a scheduled return from the stop-list into account.
+func (f *Food) Available(at time.Time) bool {
+	return f.IsAvailable || (f.AvailableAgainAt != nil && !f.AvailableAgainAt.After(at))
 }
@@ -0,0 +1,101 @@
+package model
+
+import "gorm.io/gorm"
+
+// ModifierGroup is a choice offered with a food, such as a size, the milk type
+// or paid add-ons. MaxSelect of 0 means any number of options may be picked.
+type ModifierGroup struct {
+	gorm.Model
+	FoodID    uint             `json:"food_id" gorm:"index"`
+	NameTm    string           `json:"name_tm"`
+	NameRu    string           `json:"name_ru"`
+	MinSelect int              `json:"min_select"`
+	MaxSelect int              `json:"max_select"`
+	SortOrder int              `json:"sort_order" gorm:"default:0"`
+	Options   []ModifierOption `json:"options" gorm:"foreignKey:GroupID"`
+}
+
+type ModifierOption struct {
+	gorm.Model
+	GroupID    uint   `json:"group_id" gorm:"index"`
+	NameTm     string `json:"name_tm"`
+	NameRu     string `json:"name_ru"`
+	PriceDelta Money  `json:"price_delta"`
+	IsDefault  bool   `json:"is_default"`
+	SortOrder  int    `json:"sort_order" gorm:"default:0"`
+}
+
+func (g *ModifierGroup) Validate() error {
+	if g.NameTm == "" && g.NameRu == "" {
+		return invalid("name_tm", "NAME_REQUIRED", "at least one modifier group name (TM or RU) is required")
+	}
+	if len(g.Options) == 0 {
+		return invalid("options", "EMPTY_LIST", "a modifier group needs at least one option")
+	}
+	if g.MinSelect < 0 || g.MaxSelect < 0 {
+		return invalid("min_select", "NEGATIVE", "min_select and max_select must not be negative")
+	}
+	if g.MaxSelect > 0 && g.MinSelect > g.MaxSelect {
+		return invalid("min_select", "MIN_EXCEEDS_MAX", "min_select must not exceed max_select")
+	}
+	if g.MinSelect > len(g.Options) {
+		return invalid("min_select", "MIN_EXCEEDS_OPTIONS", "min_select must not exceed the number of options")
+	}
+
+	defaults := 0
+	for _, option := range g.Options {
+		if option.NameTm == "" && option.NameRu == "" {
+			return invalid("options", "NAME_REQUIRED", "at least one option name (TM or RU) is required")
+		}
+		if option.IsDefault {
+			defaults++
+		}
+	}
+	if g.MaxSelect > 0 && defaults > g.MaxSelect {
+		return invalid("options", "TOO_MANY_DEFAULTS", "more default options than max_select allows")
+	}
+	return nil
+}
+
+// SelectionPrice checks the chosen option IDs against the food's modifier
+// groups and returns their total price delta. ModifierGroups and their
+// options must be loaded.
+func (f *Food) SelectionPrice(optionIDs []uint) (Money, error) {
+	chosen := make(map[uint]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		if chosen[id] {
+			return 0, invalid("option_ids", "OPTION_SELECTED_TWICE", "option %d is selected twice", id)
+		}
+		chosen[id] = true
+	}
+
+	var total Money
+	matched := 0
+	for _, group := range f.ModifierGroups {
+		selected := 0
+		for _, option := range group.Options {
+			if chosen[option.ID] {
+				selected++
+				total += option.PriceDelta
+			}
+		}
+		if selected < group.MinSelect {
+			return 0, invalid("option_ids", "TOO_FEW_OPTIONS", "choose at least %d option(s) in %q", group.MinSelect, group.displayName())
+		}
+		if group.MaxSelect > 0 && selected > group.MaxSelect {
+			return 0, invalid("option_ids", "TOO_MANY_OPTIONS", "choose at most %d option(s) in %q", group.MaxSelect, group.displayName())
+		}
+		matched += selected
+	}
+	if matched != len(chosen) {
+		return 0, invalid("option_ids", "UNKNOWN_OPTIONS", "some options do not belong to this food")
+	}
+	return total, nil
+}
+
+func (g *ModifierGroup) displayName() string {
+	if g.NameTm != "" {
+		return g.NameTm
+	}
+	return g.NameRu
+}
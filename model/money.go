@@ -0,0 +1,112 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor currency units, e.g. teňňe for TMT.
+// All supported currencies use two decimal places.
+type Money int64
+
+const DefaultCurrency = "TMT"
+
+// SupportedCurrencies are the ISO 4217 codes a cafe may use. Money always has
+// two decimal places, so currencies with other minor units such as JPY or KWD
+// are left out.
+var SupportedCurrencies = []string{"TMT", "USD", "EUR", "RUB", "TRY", "KZT", "UZS", "CNY", "GBP", "AED"}
+
+// IsSupportedCurrency reports whether code is one of SupportedCurrencies.
+func IsSupportedCurrency(code string) bool {
+	for _, supported := range SupportedCurrencies {
+		if code == supported {
+			return true
+		}
+	}
+	return false
+}
+
+var moneyPattern = regexp.MustCompile(`^(-)?(\d+)(?:[.,](\d{1,2}))?$`)
+
+// ParseMoney parses a decimal amount such as "12", "12.5" or "12,50".
+// Anything else, including more than two decimals, exponents or
+// thousands separators, is rejected instead of being rounded.
+func ParseMoney(value string) (Money, error) {
+	match := moneyPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0, invalidAmount(fmt.Sprintf("invalid amount %q", value))
+	}
+
+	units, err := strconv.ParseInt(match[2], 10, 64)
+	if err != nil || units > (1<<63-1)/100 {
+		return 0, invalidAmount(fmt.Sprintf("amount %q is out of range", value))
+	}
+	cents := int64(0)
+	if match[3] != "" {
+		cents, _ = strconv.ParseInt((match[3] + "0")[:2], 10, 64)
+	}
+
+	amount := Money(units*100 + cents)
+	if match[1] == "-" {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func invalidAmount(message string) *ValidationError {
+	return &ValidationError{Field: "amount", Code: "INVALID_AMOUNT", message: message}
+}
+
+// String formats the amount with exactly two decimals, e.g. "12.50".
+func (m Money) String() string {
+	sign := ""
+	value := int64(m)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, value/100, value%100)
+}
+
+// MarshalJSON writes the amount as a JSON number with two decimals.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or string in the format of ParseMoney.
+// A JSON null leaves the amount unchanged, like it does for other types.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	text := string(bytes.Trim(data, `"`))
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// UnmarshalParam parses form and query values in the format of ParseMoney.
+func (m *Money) UnmarshalParam(param string) error {
+	parsed, err := ParseMoney(param)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// AddPercent returns the amount changed by basisPoints hundredths of a percent
+// (1050 is +10.5%), rounded half away from zero to the nearest minor unit.
+func (m Money) AddPercent(basisPoints int64) Money {
+	scaled := int64(m) * (10000 + basisPoints)
+	if scaled < 0 {
+		return Money((scaled - 5000) / 10000)
+	}
+	return Money((scaled + 5000) / 10000)
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Money
+	}{
+		{"12", 1200},
+		{"12.5", 1250},
+		{"12,50", 1250},
+		{" 7.05 ", 705},
+		{"0.01", 1},
+		{"-3.1", -310},
+		{"92233720368547758", 9223372036854775800},
+	} {
+		got, err := ParseMoney(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseMoney(%q) = %d, %v; want %d", tc.in, got, err, tc.want)
+		}
+	}
+
+	for _, in := range []string{"", "abc", "12.345", "1e3", "1,000.00", "+5", ".5", "92233720368547759"} {
+		if got, err := ParseMoney(in); err == nil {
+			t.Errorf("ParseMoney(%q) = %d, want an error", in, got)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	for _, tc := range []struct {
+		in   Money
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{1250, "12.50"},
+		{-310, "-3.10"},
+	} {
+		if got := tc.in.String(); got != tc.want {
+			t.Errorf("Money(%d).String() = %q, want %q", int64(tc.in), got, tc.want)
+		}
+	}
+}
+
+func TestAddPercent(t *testing.T) {
+	for _, tc := range []struct {
+		amount      Money
+		basisPoints int64
+		want        Money
+	}{
+		{1000, 1050, 1105},
+		{4550, -1500, 3868}, // 38.675 rounds up
+		{999, -1500, 849},   // 8.4915 rounds down
+		{5, 1000, 6},        // 0.055 rounds half up
+		{-5, 1000, -6},      // and half away from zero below it
+		{4550, -10000, 0},
+		{4550, 0, 4550},
+	} {
+		if got := tc.amount.AddPercent(tc.basisPoints); got != tc.want {
+			t.Errorf("Money(%d).AddPercent(%d) = %d, want %d", int64(tc.amount), tc.basisPoints, int64(got), int64(tc.want))
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	var body struct {
+		Price Money  `json:"price"`
+		Delta *Money `json:"delta"`
+	}
+	body.Price = 4550
+	if err := json.Unmarshal([]byte(`{"price": null, "delta": null}`), &body); err != nil {
+		t.Fatalf("null amounts: %v", err)
+	}
+	if body.Price != 4550 || body.Delta != nil {
+		t.Errorf("null changed the amounts to %v, %v", body.Price, body.Delta)
+	}
+
+	if err := json.Unmarshal([]byte(`{"price": "12,50", "delta": 3}`), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Price != 1250 || body.Delta == nil || *body.Delta != 300 {
+		t.Errorf("amounts = %v, %v; want 12.50, 3.00", body.Price, body.Delta)
+	}
+
+	if err := json.Unmarshal([]byte(`{"price": 1.005}`), &body); err == nil {
+		t.Errorf("three decimals were accepted")
+	}
+}
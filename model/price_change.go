@@ -0,0 +1,38 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type PriceChangeStatus string
+
+const (
+	PriceChangePending   PriceChangeStatus = "pending"
+	PriceChangeApplied   PriceChangeStatus = "applied"
+	PriceChangeCancelled PriceChangeStatus = "cancelled"
+)
+
+type PriceChangeSource string
+
+const (
+	PriceChangeManual    PriceChangeSource = "manual"
+	PriceChangeScheduled PriceChangeSource = "scheduled"
+	PriceChangeBulk      PriceChangeSource = "bulk"
+)
+
+// FoodPriceChange is one entry of a food's price history. Changes with an
+// EffectiveAt in the future stay pending until the price scheduler applies
+// them; OldPrice is filled in at that moment.
+type FoodPriceChange struct {
+	gorm.Model
+	FoodID      uint              `json:"food_id" gorm:"index"`
+	CafeID      uint              `json:"cafe_id" gorm:"index"`
+	OldPrice    Money             `json:"old_price"`
+	NewPrice    Money             `json:"new_price"`
+	ChangedBy   uint              `json:"changed_by"`
+	Source      PriceChangeSource `json:"source" gorm:"size:16"`
+	Status      PriceChangeStatus `json:"status" gorm:"size:16;index"`
+	EffectiveAt time.Time         `json:"effective_at" gorm:"index"`
+	AppliedAt   *time.Time        `json:"applied_at"`
+}
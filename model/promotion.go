@@ -0,0 +1,138 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type PromotionType string
+
+const (
+	PromotionPercent PromotionType = "percent"
+	PromotionFixed   PromotionType = "fixed"
+	PromotionBundle  PromotionType = "bundle"
+)
+
+// Promotion discounts foods or whole categories. Value depends on Type:
+// the percentage taken off (20.00 is 20%), the amount taken off each item,
+// or the total price of one bundle made of all targets.
+// The promotion runs between StartsAt and EndsAt, when set, and only during
+// its schedules, when it has any.
+type Promotion struct {
+	gorm.Model
+	CafeID    uint              `json:"cafe_id" gorm:"index"`
+	NameTm    string            `json:"name_tm"`
+	NameRu    string            `json:"name_ru"`
+	Type      PromotionType     `json:"type" gorm:"size:16"`
+	Value     Money             `json:"value"`
+	StartsAt  *time.Time        `json:"starts_at"`
+	EndsAt    *time.Time        `json:"ends_at"`
+	IsActive  bool              `json:"is_active" gorm:"not null"`
+	Targets   []PromotionTarget `json:"targets" gorm:"foreignKey:PromotionID"`
+	Schedules []MenuSchedule    `json:"schedules" gorm:"foreignKey:PromotionID"`
+}
+
+// PromotionTarget is a food or a whole category a promotion applies to.
+// For bundles every target is one slot that Quantity items must fill.
+type PromotionTarget struct {
+	ID          uint  `json:"id" gorm:"primarykey"`
+	PromotionID uint  `json:"promotion_id" gorm:"index"`
+	FoodID      *uint `json:"food_id"`
+	CategoryID  *uint `json:"category_id"`
+	Quantity    int   `json:"quantity" gorm:"not null;default:1"`
+	// Subtree holds CategoryID and its subcategories once they are resolved,
+	// see CategorySet.
+	Subtree CategorySet `json:"-" gorm:"-"`
+}
+
+func (p *Promotion) Validate() error {
+	if p.NameTm == "" && p.NameRu == "" {
+		return invalid("name_tm", "NAME_REQUIRED", "at least one promotion name (TM or RU) is required")
+	}
+	switch p.Type {
+	case PromotionPercent:
+		if p.Value <= 0 || p.Value > 100*100 {
+			return invalid("value", "INVALID_PERCENT_VALUE", "percent value must be greater than 0 and at most 100")
+		}
+	case PromotionFixed, PromotionBundle:
+		if p.Value <= 0 {
+			return invalid("value", "INVALID_PRICE", "value must be a positive amount")
+		}
+	default:
+		return invalid("type", "INVALID_CHOICE", "promotion type must be %s", "percent, fixed, bundle")
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return invalid("ends_at", "MUST_BE_AFTER", "ends_at must be after %s", "starts_at")
+	}
+
+	if len(p.Targets) == 0 {
+		return invalid("targets", "EMPTY_LIST", "a promotion needs at least one food or category")
+	}
+	items := 0
+	for _, target := range p.Targets {
+		if (target.FoodID == nil) == (target.CategoryID == nil) {
+			return invalid("targets", "TARGET_REQUIRED", "every target needs either a food_id or a category_id")
+		}
+		if target.Quantity < 1 {
+			return invalid("quantity", "MIN_VALUE", "target quantity must be at least %d", 1)
+		}
+		items += target.Quantity
+	}
+	if p.Type == PromotionBundle && items < 2 {
+		return invalid("targets", "BUNDLE_TOO_SMALL", "a bundle needs at least two items")
+	}
+
+	for i := range p.Schedules {
+		if err := p.Schedules[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TargetsFood reports whether the promotion applies to the given food.
+func (p *Promotion) TargetsFood(food *Food) bool {
+	for _, target := range p.Targets {
+		if target.Matches(food) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether the target covers food, directly or through its
+// category or any parent of it.
+func (t *PromotionTarget) Matches(food *Food) bool {
+	return (t.FoodID != nil && *t.FoodID == food.ID) ||
+		(t.CategoryID != nil && t.Subtree.covers(*t.CategoryID, food.CategoryID))
+}
+
+// RunningAt reports whether the promotion applies at t. The schedules are
+// evaluated in loc, the cafe's timezone.
+func (p *Promotion) RunningAt(t time.Time, loc *time.Location) bool {
+	if !p.IsActive {
+		return false
+	}
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !t.Before(*p.EndsAt) {
+		return false
+	}
+	return ScheduledNow(p.Schedules, t.In(loc))
+}
+
+// ItemPrice returns the price of one item after a percent or fixed discount.
+// Bundles do not change single item prices.
+func (p *Promotion) ItemPrice(price Money) Money {
+	switch p.Type {
+	case PromotionPercent:
+		return price.AddPercent(-int64(p.Value))
+	case PromotionFixed:
+		if p.Value >= price {
+			return 0
+		}
+		return price - p.Value
+	}
+	return price
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// MenuSchedule is a weekly time range during which a category or a food is
+// served, or a promotion runs. Items without schedules are always served,
+// otherwise any matching schedule is enough.
+// Times are "HH:MM" in the cafe's timezone; an end time before the start time
+// means the range continues past midnight into the next day.
+type MenuSchedule struct {
+	gorm.Model
+	CafeID      uint   `json:"cafe_id" gorm:"index"`
+	CategoryID  *uint  `json:"category_id,omitempty" gorm:"index"`
+	FoodID      *uint  `json:"food_id,omitempty" gorm:"index"`
+	PromotionID *uint  `json:"promotion_id,omitempty" gorm:"index"`
+	Weekday     int    `json:"weekday"`
+	StartTime   string `json:"start_time" gorm:"size:5"`
+	EndTime     string `json:"end_time" gorm:"size:5"`
+}
+
+func (s *MenuSchedule) Validate() error {
+	if s.Weekday < 0 || s.Weekday > 6 {
+		return invalid("weekday", "INVALID_WEEKDAY", "weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	if _, err := parseClock(s.StartTime); err != nil {
+		return invalid("start_time", "INVALID_TIME", "start_time must be a time as HH:MM")
+	}
+	if _, err := parseClock(s.EndTime); err != nil {
+		return invalid("end_time", "INVALID_TIME", "end_time must be a time as HH:MM")
+	}
+	return nil
+}
+
+// Matches reports whether t, already converted to the cafe's timezone, falls into the schedule.
+func (s *MenuSchedule) Matches(t time.Time) bool {
+	start, err := parseClock(s.StartTime)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(s.EndTime)
+	if err != nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	weekday := int(t.Weekday())
+	nextDay := (s.Weekday + 1) % 7
+
+	switch {
+	case start == end:
+		return weekday == s.Weekday
+	case start < end:
+		return weekday == s.Weekday && minute >= start && minute < end
+	default:
+		return (weekday == s.Weekday && minute >= start) || (weekday == nextDay && minute < end)
+	}
+}
+
+// ScheduledNow reports whether an item with the given schedules is served at t.
+func ScheduledNow(schedules []MenuSchedule, t time.Time) bool {
+	if len(schedules) == 0 {
+		return true
+	}
+	for i := range schedules {
+		if schedules[i].Matches(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseClock(value string) (int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMenuScheduleMatches(t *testing.T) {
+	// 2026-10-19 is a Monday.
+	at := func(day int, clock string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", "2026-10-19 "+clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed.AddDate(0, 0, day)
+	}
+	lunch := MenuSchedule{Weekday: 1, StartTime: "09:00", EndTime: "17:00"}
+	friday := MenuSchedule{Weekday: 5, StartTime: "22:00", EndTime: "02:00"}
+	saturday := MenuSchedule{Weekday: 6, StartTime: "22:00", EndTime: "02:00"}
+	monday := MenuSchedule{Weekday: 1, StartTime: "00:00", EndTime: "00:00"}
+	mondayFrom8 := MenuSchedule{Weekday: 1, StartTime: "08:00", EndTime: "08:00"}
+	broken := MenuSchedule{Weekday: 1, StartTime: "25:00", EndTime: "17:00"}
+
+	for _, tc := range []struct {
+		name     string
+		schedule MenuSchedule
+		at       time.Time
+		want     bool
+	}{
+		{"start of a range", lunch, at(0, "09:00"), true},
+		{"inside a range", lunch, at(0, "16:59"), true},
+		{"end is exclusive", lunch, at(0, "17:00"), false},
+		{"before a range", lunch, at(0, "08:59"), false},
+		{"other weekday", lunch, at(1, "10:00"), false},
+		{"overnight before midnight", friday, at(4, "23:30"), true},
+		{"overnight after midnight", friday, at(5, "01:59"), true},
+		{"overnight end is exclusive", friday, at(5, "02:00"), false},
+		{"overnight before start", friday, at(4, "21:59"), false},
+		{"overnight start on next day", friday, at(5, "23:00"), false},
+		{"overnight into the next week", saturday, at(6, "01:00"), true},
+		{"whole day at midnight", monday, at(0, "00:00"), true},
+		{"whole day before midnight", monday, at(0, "23:59"), true},
+		{"whole day ends at midnight", monday, at(1, "00:00"), false},
+		{"equal times mean the whole day", mondayFrom8, at(0, "07:00"), true},
+		{"invalid time never matches", broken, at(0, "10:00"), false},
+	} {
+		if got := tc.schedule.Matches(tc.at); got != tc.want {
+			t.Errorf("%s: Matches(%s) = %v, want %v", tc.name, tc.at.Format("Mon 15:04"), got, tc.want)
+		}
+	}
+}
+
+func TestScheduledNow(t *testing.T) {
+	monday := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	if !ScheduledNow(nil, monday) {
+		t.Errorf("an item without schedules is not served")
+	}
+	schedules := []MenuSchedule{
+		{Weekday: 0, StartTime: "09:00", EndTime: "17:00"},
+		{Weekday: 1, StartTime: "11:00", EndTime: "13:00"},
+	}
+	if !ScheduledNow(schedules, monday) {
+		t.Errorf("an item is not served when one of its schedules matches")
+	}
+	if ScheduledNow(schedules[:1], monday) {
+		t.Errorf("an item is served when none of its schedules match")
+	}
+}
@@ -0,0 +1,40 @@
+package model
+
+import "gorm.io/gorm"
+
+type TagKind string
+
+const (
+	TagAllergen TagKind = "allergen"
+	TagDietary  TagKind = "dietary"
+)
+
+// Tag marks foods with allergens or dietary properties. Predefined tags have
+// no CafeID and are shared by every cafe; cafes can add their own on top.
+type Tag struct {
+	gorm.Model
+	CafeID *uint   `json:"cafe_id" gorm:"index"`
+	Code   string  `json:"code" gorm:"size:64;index"`
+	Kind   TagKind `json:"kind" gorm:"size:16"`
+	NameTM string  `json:"name_tm"`
+	NameRU string  `json:"name_ru"`
+	NameEN string  `json:"name_en"`
+	Foods  []Food  `json:"-" gorm:"many2many:food_tags"`
+}
+
+// PredefinedTags are created on startup when missing.
+var PredefinedTags = []Tag{
+	{Code: "gluten", Kind: TagAllergen, NameTM: "Glýuten", NameRU: "Глютен", NameEN: "Gluten"},
+	{Code: "nuts", Kind: TagAllergen, NameTM: "Hozlar", NameRU: "Орехи", NameEN: "Nuts"},
+	{Code: "peanuts", Kind: TagAllergen, NameTM: "Ýer hozy", NameRU: "Арахис", NameEN: "Peanuts"},
+	{Code: "milk", Kind: TagAllergen, NameTM: "Süýt", NameRU: "Молоко", NameEN: "Milk"},
+	{Code: "eggs", Kind: TagAllergen, NameTM: "Ýumurtga", NameRU: "Яйца", NameEN: "Eggs"},
+	{Code: "fish", Kind: TagAllergen, NameTM: "Balyk", NameRU: "Рыба", NameEN: "Fish"},
+	{Code: "shellfish", Kind: TagAllergen, NameTM: "Deňiz önümleri", NameRU: "Моллюски", NameEN: "Shellfish"},
+	{Code: "soy", Kind: TagAllergen, NameTM: "Soýa", NameRU: "Соя", NameEN: "Soy"},
+	{Code: "sesame", Kind: TagAllergen, NameTM: "Künjüt", NameRU: "Кунжут", NameEN: "Sesame"},
+	{Code: "vegan", Kind: TagDietary, NameTM: "Wegan", NameRU: "Веганское", NameEN: "Vegan"},
+	{Code: "vegetarian", Kind: TagDietary, NameTM: "Wegetarian", NameRU: "Вегетарианское", NameEN: "Vegetarian"},
+	{Code: "halal", Kind: TagDietary, NameTM: "Halal", NameRU: "Халяль", NameEN: "Halal"},
+	{Code: "sugar_free", Kind: TagDietary, NameTM: "Şekersiz", NameRU: "Без сахара", NameEN: "Sugar free"},
+}
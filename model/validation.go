@@ -0,0 +1,24 @@
+package model
+
+import "fmt"
+
+// ValidationError reports an invalid field. Code is a stable identifier the
+// API uses to localize the message, and Args fill its placeholders.
+type ValidationError struct {
+	Field   string
+	Code    string
+	Args    []any
+	message string
+}
+
+func invalid(field, code, message string, args ...any) *ValidationError {
+	return &ValidationError{Field: field, Code: code, Args: args, message: fmt.Sprintf(message, args...)}
+}
+
+func (e *ValidationError) Error() string {
+	return e.message
+}
+
+func (e *ValidationError) ErrorField() string { return e.Field }
+func (e *ValidationError) ErrorCode() string  { return e.Code }
+func (e *ValidationError) ErrorArgs() []any   { return e.Args }
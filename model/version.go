@@ -0,0 +1,30 @@
+package model
+
+import "gorm.io/gorm"
+
+// Foods, categories and cafes carry a version for optimistic locking. Writes
+// of whole records with Save set the next version themselves and only match
+// the version they read; updates of single columns, such as a stop-list
+// toggle or a scheduled price change, bump it here so that an earlier read
+// no longer matches either.
+
+func (f *Food) BeforeUpdate(tx *gorm.DB) error {
+	bumpVersion(tx)
+	return nil
+}
+
+func (c *FoodCategory) BeforeUpdate(tx *gorm.DB) error {
+	bumpVersion(tx)
+	return nil
+}
+
+func (c *Cafe) BeforeUpdate(tx *gorm.DB) error {
+	bumpVersion(tx)
+	return nil
+}
+
+func bumpVersion(tx *gorm.DB) {
+	if _, ok := tx.Statement.Dest.(map[string]interface{}); ok {
+		tx.Statement.SetColumn("version", gorm.Expr("version + 1"))
+	}
+}
@@ -0,0 +1,118 @@
+package pricing
+
+import (
+	"cafe/model"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// RecordChange stores an applied price change of food in the price history.
+// The caller is responsible for saving the new price on the food itself.
+func RecordChange(tx *gorm.DB, food *model.Food, newPrice model.Money, changedBy uint, source model.PriceChangeSource) error {
+	if food.Price == newPrice {
+		return nil
+	}
+	now := time.Now()
+	return tx.Create(&model.FoodPriceChange{
+		FoodID:      food.ID,
+		CafeID:      food.CafeID,
+		OldPrice:    food.Price,
+		NewPrice:    newPrice,
+		ChangedBy:   changedBy,
+		Source:      source,
+		Status:      model.PriceChangeApplied,
+		EffectiveAt: now,
+		AppliedAt:   &now,
+	}).Error
+}
+
+// ChangePrice sets a new price on food right away and records it in the history.
+func ChangePrice(tx *gorm.DB, food *model.Food, newPrice model.Money, changedBy uint, source model.PriceChangeSource) error {
+	if err := RecordChange(tx, food, newPrice, changedBy, source); err != nil {
+		return err
+	}
+	if err := tx.Model(food).Update("price", newPrice).Error; err != nil {
+		return err
+	}
+	food.Price = newPrice
+	// The update bumped the version in the database, see model.Food.BeforeUpdate.
+	food.Version++
+	return nil
+}
+
+// SchedulePrice stores a price change that takes effect at effectiveAt.
+func SchedulePrice(tx *gorm.DB, food *model.Food, newPrice model.Money, effectiveAt time.Time, changedBy uint, source model.PriceChangeSource) (model.FoodPriceChange, error) {
+	change := model.FoodPriceChange{
+		FoodID:      food.ID,
+		CafeID:      food.CafeID,
+		NewPrice:    newPrice,
+		ChangedBy:   changedBy,
+		Source:      source,
+		Status:      model.PriceChangePending,
+		EffectiveAt: effectiveAt,
+	}
+	err := tx.Create(&change).Error
+	return change, err
+}
+
+// ApplyDueChanges applies every pending price change whose effective time has
+// passed and returns how many were applied.
+func ApplyDueChanges(db *gorm.DB, now time.Time) (int, error) {
+	var due []model.FoodPriceChange
+	err := db.Where("status = ? AND effective_at <= ?", model.PriceChangePending, now).
+		Order("effective_at, id").
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, change := range due {
+		wasApplied := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// The change may have been cancelled since it was listed.
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("status = ?", model.PriceChangePending).
+				First(&change, change.ID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			var food model.Food
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&food, change.FoodID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return tx.Model(&change).Update("status", model.PriceChangeCancelled).Error
+			}
+			if err != nil {
+				return err
+			}
+
+			oldPrice := food.Price
+			if err := tx.Model(&food).Update("price", change.NewPrice).Error; err != nil {
+				return err
+			}
+			if err := model.TouchCafe(tx, food.CafeID); err != nil {
+				return err
+			}
+			appliedAt := time.Now()
+			wasApplied = true
+			return tx.Model(&change).Updates(map[string]interface{}{
+				"old_price":  oldPrice,
+				"status":     model.PriceChangeApplied,
+				"applied_at": &appliedAt,
+			}).Error
+		})
+		if err != nil {
+			return applied, err
+		}
+		if wasApplied {
+			applied++
+		}
+	}
+	return applied, nil
+}
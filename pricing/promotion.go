@@ -0,0 +1,220 @@
+package pricing
+
+import (
+	"cafe/model"
+	"sort"
+	"time"
+)
+
+// Running returns the promotions that apply at t in the cafe's timezone loc.
+func Running(promotions []model.Promotion, t time.Time, loc *time.Location) []model.Promotion {
+	running := make([]model.Promotion, 0, len(promotions))
+	for _, promotion := range promotions {
+		if promotion.RunningAt(t, loc) {
+			running = append(running, promotion)
+		}
+	}
+	return running
+}
+
+// BestItemPrice returns the lowest price of one food under the percent and
+// fixed promotions, which never stack, and the promotion giving it.
+// The promotion is nil when none of them lowers the price.
+func BestItemPrice(food *model.Food, promotions []model.Promotion) (model.Money, *model.Promotion) {
+	best := food.Price
+	var bestPromotion *model.Promotion
+	for i := range promotions {
+		promotion := &promotions[i]
+		if promotion.Type == model.PromotionBundle || !promotion.TargetsFood(food) {
+			continue
+		}
+		if price := promotion.ItemPrice(food.Price); price < best {
+			best = price
+			bestPromotion = promotion
+		}
+	}
+	return best, bestPromotion
+}
+
+// OrderItem is one order line. Extras is the per-unit price of the chosen
+// modifiers, which is charged on top of the food price and never discounted.
+type OrderItem struct {
+	Food     model.Food
+	Quantity int
+	Extras   model.Money
+}
+
+type QuoteLine struct {
+	FoodID    uint        `json:"food_id"`
+	Quantity  int         `json:"quantity"`
+	UnitPrice model.Money `json:"unit_price"`
+	Subtotal  model.Money `json:"subtotal"`
+	Discount  model.Money `json:"discount"`
+	Total     model.Money `json:"total"`
+}
+
+type AppliedPromotion struct {
+	PromotionID uint        `json:"promotion_id"`
+	Times       int         `json:"times"`
+	Discount    model.Money `json:"discount"`
+}
+
+type Quote struct {
+	Lines      []QuoteLine        `json:"lines"`
+	Promotions []AppliedPromotion `json:"promotions"`
+	Subtotal   model.Money        `json:"subtotal"`
+	Discount   model.Money        `json:"discount"`
+	Total      model.Money        `json:"total"`
+}
+
+type orderUnit struct {
+	line     int
+	food     *model.Food
+	consumed bool
+	discount model.Money
+}
+
+// QuoteOrder prices an order under the given running promotions. Bundles
+// are applied first, as often as the order allows and always to the most
+// expensive matching items; every remaining item then gets its best
+// single-item discount.
+func QuoteOrder(items []OrderItem, promotions []model.Promotion) Quote {
+	var units []*orderUnit
+	for line := range items {
+		for n := 0; n < items[line].Quantity; n++ {
+			units = append(units, &orderUnit{line: line, food: &items[line].Food})
+		}
+	}
+	// Most expensive first, so bundles pick the items saving the most.
+	sort.SliceStable(units, func(i, j int) bool {
+		return units[i].food.Price > units[j].food.Price
+	})
+
+	applied := map[uint]*AppliedPromotion{}
+	var order []uint
+	record := func(promotion *model.Promotion, discount model.Money) {
+		entry, ok := applied[promotion.ID]
+		if !ok {
+			entry = &AppliedPromotion{PromotionID: promotion.ID}
+			applied[promotion.ID] = entry
+			order = append(order, promotion.ID)
+		}
+		entry.Times++
+		entry.Discount += discount
+	}
+
+	for i := range promotions {
+		promotion := &promotions[i]
+		if promotion.Type != model.PromotionBundle {
+			continue
+		}
+		for {
+			picked := pickBundle(promotion, units)
+			if picked == nil {
+				break
+			}
+			record(promotion, spreadDiscount(picked, promotion.Value))
+		}
+	}
+
+	for _, unit := range units {
+		if unit.consumed {
+			continue
+		}
+		price, promotion := BestItemPrice(unit.food, promotions)
+		if promotion != nil {
+			unit.discount = unit.food.Price - price
+			record(promotion, unit.discount)
+		}
+	}
+
+	quote := Quote{Lines: make([]QuoteLine, len(items)), Promotions: []AppliedPromotion{}}
+	for line, item := range items {
+		unitPrice := item.Food.Price + item.Extras
+		quote.Lines[line] = QuoteLine{
+			FoodID:    item.Food.ID,
+			Quantity:  item.Quantity,
+			UnitPrice: unitPrice,
+			Subtotal:  unitPrice * model.Money(item.Quantity),
+		}
+	}
+	for _, unit := range units {
+		quote.Lines[unit.line].Discount += unit.discount
+	}
+	for line := range quote.Lines {
+		quote.Lines[line].Total = quote.Lines[line].Subtotal - quote.Lines[line].Discount
+		quote.Subtotal += quote.Lines[line].Subtotal
+		quote.Discount += quote.Lines[line].Discount
+	}
+	quote.Total = quote.Subtotal - quote.Discount
+	for _, id := range order {
+		quote.Promotions = append(quote.Promotions, *applied[id])
+	}
+	return quote
+}
+
+// pickBundle marks the units forming one bundle as consumed and returns them,
+// or returns nil when the remaining units cannot form a bundle that saves money.
+func pickBundle(promotion *model.Promotion, units []*orderUnit) []*orderUnit {
+	// Food targets are stricter than category targets, so fill them first.
+	targets := append([]model.PromotionTarget(nil), promotion.Targets...)
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].FoodID != nil && targets[j].FoodID == nil
+	})
+
+	var picked []*orderUnit
+	release := func() {
+		for _, unit := range picked {
+			unit.consumed = false
+		}
+	}
+
+	for _, target := range targets {
+		for n := 0; n < target.Quantity; n++ {
+			found := false
+			for _, unit := range units {
+				if !unit.consumed && target.Matches(unit.food) {
+					unit.consumed = true
+					picked = append(picked, unit)
+					found = true
+					break
+				}
+			}
+			if !found {
+				release()
+				return nil
+			}
+		}
+	}
+
+	var regular model.Money
+	for _, unit := range picked {
+		regular += unit.food.Price
+	}
+	if regular <= promotion.Value {
+		release()
+		return nil
+	}
+	return picked
+}
+
+// spreadDiscount splits the saving of a bundle across its units in
+// proportion to their prices and returns the total saving.
+func spreadDiscount(units []*orderUnit, bundlePrice model.Money) model.Money {
+	var regular model.Money
+	for _, unit := range units {
+		regular += unit.food.Price
+	}
+	saving := regular - bundlePrice
+
+	remaining := saving
+	for i, unit := range units {
+		if i == len(units)-1 {
+			unit.discount = remaining
+			break
+		}
+		unit.discount = saving * unit.food.Price / regular
+		remaining -= unit.discount
+	}
+	return saving
+}
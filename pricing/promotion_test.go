@@ -0,0 +1,206 @@
+package pricing
+
+import (
+	"cafe/model"
+	"gorm.io/gorm"
+	"reflect"
+	"testing"
+)
+
+const (
+	mains  uint = 1
+	drinks uint = 2
+	coffee uint = 3 // a subcategory of drinks
+)
+
+var (
+	bread = model.Food{Model: gormID(5), CategoryID: mains, Price: 999}
+	plov  = model.Food{Model: gormID(1), CategoryID: mains, Price: 4500}
+	tea   = model.Food{Model: gormID(2), CategoryID: drinks, Price: 1000}
+	juice = model.Food{Model: gormID(3), CategoryID: drinks, Price: 2000}
+	latte = model.Food{Model: gormID(4), CategoryID: coffee, Price: 2500}
+)
+
+func gormID(id uint) gorm.Model {
+	return gorm.Model{ID: id}
+}
+
+func foodTarget(food model.Food, quantity int) model.PromotionTarget {
+	return model.PromotionTarget{FoodID: &food.ID, Quantity: quantity}
+}
+
+func categoryTarget(categoryID uint, quantity int, subtree ...uint) model.PromotionTarget {
+	target := model.PromotionTarget{CategoryID: &categoryID, Quantity: quantity}
+	if len(subtree) > 0 {
+		target.Subtree = model.CategorySet{categoryID: true}
+		for _, id := range subtree {
+			target.Subtree[id] = true
+		}
+	}
+	return target
+}
+
+func promotion(id uint, kind model.PromotionType, value model.Money, targets ...model.PromotionTarget) model.Promotion {
+	return model.Promotion{Model: gormID(id), Type: kind, Value: value, IsActive: true, Targets: targets}
+}
+
+func TestBestItemPrice(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		food       model.Food
+		promotions []model.Promotion
+		want       model.Money
+		wantID     uint
+	}{
+		{"no promotions", plov, nil, 4500, 0},
+		{"percent", plov, []model.Promotion{promotion(1, model.PromotionPercent, 1000, foodTarget(plov, 1))}, 4050, 1},
+		{"percent rounds to the nearest unit", bread, []model.Promotion{promotion(1, model.PromotionPercent, 1500, foodTarget(bread, 1))}, 849, 1},
+		{"fixed", plov, []model.Promotion{promotion(1, model.PromotionFixed, 500, foodTarget(plov, 1))}, 4000, 1},
+		{"fixed never goes below zero", tea, []model.Promotion{promotion(1, model.PromotionFixed, 1500, foodTarget(tea, 1))}, 0, 1},
+		{"other food", tea, []model.Promotion{promotion(1, model.PromotionPercent, 1000, foodTarget(plov, 1))}, 1000, 0},
+		{"bundles are ignored", tea, []model.Promotion{promotion(1, model.PromotionBundle, 500, foodTarget(tea, 2))}, 1000, 0},
+		{"overlapping targets take the lowest price", plov, []model.Promotion{
+			promotion(1, model.PromotionPercent, 1000, categoryTarget(mains, 1)),
+			promotion(2, model.PromotionFixed, 1000, foodTarget(plov, 1)),
+			promotion(3, model.PromotionPercent, 500, foodTarget(plov, 1)),
+		}, 3500, 2},
+		{"unresolved category covers itself only", latte, []model.Promotion{promotion(1, model.PromotionPercent, 1000, categoryTarget(drinks, 1))}, 2500, 0},
+		{"resolved category covers subcategories", latte, []model.Promotion{promotion(1, model.PromotionPercent, 1000, categoryTarget(drinks, 1, coffee))}, 2250, 1},
+	} {
+		price, best := BestItemPrice(&tc.food, tc.promotions)
+		var id uint
+		if best != nil {
+			id = best.ID
+		}
+		if price != tc.want || id != tc.wantID {
+			t.Errorf("%s: BestItemPrice = %v from promotion %d, want %v from %d", tc.name, price, id, tc.want, tc.wantID)
+		}
+	}
+}
+
+func TestQuoteOrder(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		items      []OrderItem
+		promotions []model.Promotion
+		discounts  []model.Money // per line
+		applied    []AppliedPromotion
+	}{
+		{
+			name:       "bundle takes the most expensive units",
+			items:      []OrderItem{{Food: tea, Quantity: 1}, {Food: latte, Quantity: 1}, {Food: juice, Quantity: 1}},
+			promotions: []model.Promotion{promotion(1, model.PromotionBundle, 3000, categoryTarget(drinks, 2, coffee))},
+			// 45.00 for 30.00 spread 25:20 over latte and juice.
+			discounts: []model.Money{0, 833, 667},
+			applied:   []AppliedPromotion{{PromotionID: 1, Times: 1, Discount: 1500}},
+		},
+		{
+			name:       "bundle repeats as often as the order allows",
+			items:      []OrderItem{{Food: juice, Quantity: 5}},
+			promotions: []model.Promotion{promotion(1, model.PromotionBundle, 3000, foodTarget(juice, 2))},
+			discounts:  []model.Money{2000},
+			applied:    []AppliedPromotion{{PromotionID: 1, Times: 2, Discount: 2000}},
+		},
+		{
+			name:       "bundle that saves nothing is skipped",
+			items:      []OrderItem{{Food: tea, Quantity: 2}},
+			promotions: []model.Promotion{promotion(1, model.PromotionBundle, 2000, foodTarget(tea, 2))},
+			discounts:  []model.Money{0},
+			applied:    []AppliedPromotion{},
+		},
+		{
+			name:  "food targets are filled before category targets",
+			items: []OrderItem{{Food: latte, Quantity: 1}, {Food: juice, Quantity: 1}},
+			promotions: []model.Promotion{promotion(1, model.PromotionBundle, 4000,
+				categoryTarget(drinks, 1, coffee), foodTarget(latte, 1))},
+			discounts: []model.Money{277, 223},
+			applied:   []AppliedPromotion{{PromotionID: 1, Times: 1, Discount: 500}},
+		},
+		{
+			name:  "units left over from bundles get single item discounts",
+			items: []OrderItem{{Food: juice, Quantity: 3}},
+			promotions: []model.Promotion{
+				promotion(1, model.PromotionBundle, 3000, foodTarget(juice, 2)),
+				promotion(2, model.PromotionPercent, 1000, foodTarget(juice, 1)),
+			},
+			discounts: []model.Money{1200},
+			applied:   []AppliedPromotion{{PromotionID: 1, Times: 1, Discount: 1000}, {PromotionID: 2, Times: 1, Discount: 200}},
+		},
+		{
+			name:       "extras are never discounted",
+			items:      []OrderItem{{Food: plov, Quantity: 2, Extras: 500}},
+			promotions: []model.Promotion{promotion(1, model.PromotionPercent, 1000, foodTarget(plov, 1))},
+			discounts:  []model.Money{900},
+			applied:    []AppliedPromotion{{PromotionID: 1, Times: 2, Discount: 900}},
+		},
+	} {
+		quote := QuoteOrder(tc.items, tc.promotions)
+
+		var discounts []model.Money
+		var subtotal, discount model.Money
+		for line, item := range tc.items {
+			got := quote.Lines[line]
+			unitPrice := item.Food.Price + item.Extras
+			if got.UnitPrice != unitPrice || got.Subtotal != unitPrice*model.Money(item.Quantity) || got.Total != got.Subtotal-got.Discount {
+				t.Errorf("%s: line %d = %+v", tc.name, line, got)
+			}
+			discounts = append(discounts, got.Discount)
+			subtotal += got.Subtotal
+			discount += got.Discount
+		}
+		if !reflect.DeepEqual(discounts, tc.discounts) {
+			t.Errorf("%s: line discounts = %v, want %v", tc.name, discounts, tc.discounts)
+		}
+		if !reflect.DeepEqual(quote.Promotions, tc.applied) {
+			t.Errorf("%s: promotions = %+v, want %+v", tc.name, quote.Promotions, tc.applied)
+		}
+		if quote.Subtotal != subtotal || quote.Discount != discount || quote.Total != subtotal-discount {
+			t.Errorf("%s: totals = %v - %v = %v, want %v - %v", tc.name, quote.Subtotal, quote.Discount, quote.Total, subtotal, discount)
+		}
+	}
+}
+
+func TestSpreadDiscount(t *testing.T) {
+	for _, tc := range []struct {
+		prices      []model.Money
+		bundlePrice model.Money
+		want        []model.Money
+	}{
+		{[]model.Money{2500, 2000}, 3000, []model.Money{833, 667}},
+		{[]model.Money{1000, 1000, 1000}, 2000, []model.Money{333, 333, 334}},
+		{[]model.Money{999, 1}, 500, []model.Money{499, 1}},
+	} {
+		var units []*orderUnit
+		for i, price := range tc.prices {
+			units = append(units, &orderUnit{food: &model.Food{Model: gormID(uint(i + 1)), Price: price}})
+		}
+		saving := spreadDiscount(units, tc.bundlePrice)
+
+		var got []model.Money
+		var total model.Money
+		for _, unit := range units {
+			got = append(got, unit.discount)
+			total += unit.discount
+		}
+		if !reflect.DeepEqual(got, tc.want) || total != saving {
+			t.Errorf("spreadDiscount(%v, %v) = %v (saving %v), want %v", tc.prices, tc.bundlePrice, got, saving, tc.want)
+		}
+	}
+}
+
+func TestPickBundleReleasesUnits(t *testing.T) {
+	units := []*orderUnit{{food: &latte}, {food: &juice}}
+	for _, bundle := range []model.Promotion{
+		promotion(1, model.PromotionBundle, 3000, foodTarget(latte, 1), foodTarget(tea, 1)),
+		promotion(2, model.PromotionBundle, 4500, foodTarget(latte, 1), foodTarget(juice, 1)),
+	} {
+		if picked := pickBundle(&bundle, units); picked != nil {
+			t.Errorf("bundle %d picked %d units, want none", bundle.ID, len(picked))
+		}
+		for _, unit := range units {
+			if unit.consumed {
+				t.Errorf("bundle %d left food %d consumed", bundle.ID, unit.food.ID)
+			}
+		}
+	}
+}
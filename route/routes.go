@@ -1,31 +1,97 @@
 package route
 
 import (
+	"cafe/config"
 	"cafe/controller"
+	"cafe/docs"
+	"cafe/metrics"
 	"cafe/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"os"
+	"path"
+	"path/filepath"
 )
 
-func CafeRoutes(router *gin.Engine) {
+func CafeRoutes(router *gin.Engine, db *gorm.DB) {
+	ctrl := controller.New(db)
+
 	cafeGroup := router.Group("/cafe")
-	cafeGroup.Use(utils.CafeMiddleware())
+	cafeGroup.Use(utils.CafeMiddleware(), ctrl.TouchMenu)
 	{
-		cafeGroup.PUT("/update", controller.UpdateMyCafe)
-		cafeGroup.GET("/my-cafe", controller.GetMyCafe)
-		cafeGroup.GET("/foods/get-my", controller.GetMyCafeFoods)
-		cafeGroup.POST("/foods/add", controller.AddFood)
-		cafeGroup.POST("/foods/add/excel", controller.BulkAddFood)
-		cafeGroup.PUT("/foods/update/:id", controller.UpdateFood)
-		cafeGroup.DELETE("/foods/delete/:id", controller.DeleteFood)
-		cafeGroup.POST("/cafe/category/add", controller.AddCategory)
-		cafeGroup.PUT("/cafe/category/update/:id", controller.UpdateCategory)
-		cafeGroup.DELETE("/cafe/category/delete/:id", controller.DeleteCategory)
-		cafeGroup.GET("/cafe/categories/get-my", controller.GetMyCategories)
+		cafeGroup.PUT("/update", ctrl.UpdateMyCafe)
+		cafeGroup.GET("/my-cafe", ctrl.GetMyCafe)
+		cafeGroup.GET("/foods/get-my", ctrl.GetMyCafeFoods)
+		cafeGroup.GET("/foods/get-my/:id", ctrl.GetMyFood)
+		cafeGroup.POST("/foods/add", ctrl.AddFood)
+		cafeGroup.POST("/foods/add/excel", ctrl.BulkAddFood)
+		cafeGroup.PUT("/foods/update/:id", ctrl.UpdateFood)
+		cafeGroup.PATCH("/foods/update/:id", ctrl.UpdateFood)
+		cafeGroup.PUT("/foods/:id/image", ctrl.UpdateFoodImage)
+		cafeGroup.DELETE("/foods/:id/image", ctrl.DeleteFoodImage)
+		cafeGroup.DELETE("/foods/delete/:id", ctrl.DeleteFood)
+		cafeGroup.PUT("/foods/reorder", ctrl.ReorderFoods)
+		cafeGroup.PUT("/foods/availability/:id", ctrl.SetFoodAvailability)
+		cafeGroup.GET("/foods/stop-list", ctrl.GetStopList)
+		cafeGroup.PUT("/foods/schedules/:id", ctrl.SetFoodSchedules)
+		cafeGroup.GET("/foods/:id/modifiers", ctrl.GetFoodModifiers)
+		cafeGroup.POST("/foods/:id/modifiers", ctrl.AddFoodModifier)
+		cafeGroup.PUT("/foods/:id/modifiers/:group_id", ctrl.UpdateFoodModifier)
+		cafeGroup.DELETE("/foods/:id/modifiers/:group_id", ctrl.DeleteFoodModifier)
+		cafeGroup.PUT("/foods/tags/:id", ctrl.SetFoodTags)
+		cafeGroup.PUT("/foods/combo/:id", ctrl.SetFoodCombo)
+		cafeGroup.GET("/foods/:id/price-history", ctrl.GetFoodPriceHistory)
+		cafeGroup.POST("/foods/:id/price-changes", ctrl.ChangeFoodPrice)
+		cafeGroup.DELETE("/foods/:id/price-changes/:change_id", ctrl.CancelFoodPriceChange)
+		cafeGroup.GET("/tags/get-my", ctrl.GetMyTags)
+		cafeGroup.POST("/tags/add", ctrl.AddTag)
+		cafeGroup.DELETE("/tags/delete/:id", ctrl.DeleteTag)
+		cafeGroup.GET("/promotions/get-my", ctrl.GetMyPromotions)
+		cafeGroup.POST("/promotions/add", ctrl.AddPromotion)
+		cafeGroup.PUT("/promotions/update/:id", ctrl.UpdatePromotion)
+		cafeGroup.DELETE("/promotions/delete/:id", ctrl.DeletePromotion)
+		cafeGroup.POST("/cafe/category/add", ctrl.AddCategory)
+		cafeGroup.PUT("/cafe/category/update/:id", ctrl.UpdateCategory)
+		cafeGroup.PATCH("/cafe/category/update/:id", ctrl.UpdateCategory)
+		cafeGroup.DELETE("/cafe/category/delete/:id", ctrl.DeleteCategory)
+		cafeGroup.GET("/cafe/categories/get-my", ctrl.GetMyCategories)
+		cafeGroup.GET("/cafe/categories/get-my/:id", ctrl.GetMyCategory)
+		cafeGroup.PUT("/categories/reorder", ctrl.ReorderCategories)
+		cafeGroup.POST("/categories/:id/raise-prices", ctrl.RaiseCategoryPrices)
+		cafeGroup.DELETE("/categories/:id/image", ctrl.DeleteCategoryImage)
+		cafeGroup.PUT("/cafe/category/schedules/:id", ctrl.SetCategorySchedules)
 	}
-	router.POST("/cafe/refresh-token", controller.RefreshTokenFunc)
-	router.POST("/cafe/auth/login", controller.LoginManager)
-	router.GET("/cafe/categories//categories/:cafe_id", controller.GetCategoriesByCafeID)
-	router.GET("/cafe/categories/foods", controller.GetCafeCategoriesWithFoods)
-	router.GET("/cafe/foods/by-category", controller.GetFoodsByCategoryID)
-	router.GET("/cafe/foods/:id", controller.GetFoodByID)
+	router.POST("/cafe/refresh-token", ctrl.RefreshTokenFunc)
+	router.POST("/cafe/auth/login", ctrl.LoginManager)
+	router.GET("/cafe/categories//categories/:cafe_id", ctrl.GetCategoriesByCafeID)
+	router.GET("/cafe/categories/foods", ctrl.GetCafeCategoriesWithFoods)
+	router.GET("/cafe/foods/by-category", ctrl.GetFoodsByCategoryID)
+	router.GET("/cafe/foods/:id", ctrl.GetFoodByID)
+	router.GET("/cafe/tags", ctrl.GetCafeTags)
+	router.POST("/cafe/orders/quote", ctrl.QuoteOrder)
+}
+
+// UploadRoutes serves the uploaded images. Their names are made from a hash of
+// the content, so browsers and proxies may keep a served image for good. A
+// missing file is not cached that way, it may still be uploaded.
+func UploadRoutes(router *gin.Engine) {
+	uploads := router.Group("/uploads", func(c *gin.Context) {
+		name := filepath.Join(config.Current.UploadDir, filepath.FromSlash(path.Clean("/"+c.Param("filepath"))))
+		if info, err := os.Stat(name); err == nil && info.Mode().IsRegular() {
+			c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		}
+	})
+	uploads.Static("/", config.Current.UploadDir)
+}
+
+// SystemRoutes registers the probes and metrics used by the orchestrator and
+// the API documentation.
+func SystemRoutes(router *gin.Engine, db *gorm.DB) {
+	ctrl := controller.New(db)
+
+	router.GET("/healthz", ctrl.Healthz)
+	router.GET("/readyz", ctrl.Readyz)
+	router.GET("/metrics", metrics.Handler())
+	router.GET("/openapi.json", docs.SpecHandler)
+	router.GET("/docs", docs.UIHandler)
 }
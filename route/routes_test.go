@@ -0,0 +1,53 @@
+package route
+
+import (
+	"cafe/docs"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var pathParam = regexp.MustCompile(`[:*](\w+)`)
+
+// TestSpecCoversRoutes fails when a registered route is missing from
+// docs/openapi.yaml or the spec documents a route that does not exist.
+func TestSpecCoversRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SystemRoutes(router, nil)
+	CafeRoutes(router, nil)
+
+	raw, err := docs.Spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	registered := map[string]bool{}
+	for _, route := range router.Routes() {
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		method := strings.ToLower(route.Method)
+		registered[method+" "+path] = true
+		if _, ok := spec.Paths[path][method]; !ok {
+			t.Errorf("%s %s is not documented in docs/openapi.yaml", route.Method, path)
+		}
+	}
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+			if !registered[method+" "+path] {
+				t.Errorf("docs/openapi.yaml documents %s %s, which is not registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
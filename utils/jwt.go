@@ -1,23 +1,20 @@
 package utils
 
 import (
+	"cafe/config"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
-	"os"
 	"time"
 )
 
 func GenerateTokens(userRole string, userID uint) (string, string, error) {
-	secretKey := os.Getenv("enweyos")
-	if secretKey == "" {
-		secretKey = "enweyos"
-	}
+	secretKey := config.Current.JWTSecret
 
 	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"user_role": userRole,
 		"id":        userID,
-		"exp":       time.Now().Add(15 * time.Minute).Unix(),
+		"exp":       time.Now().Add(config.Current.AccessTokenTTL).Unix(),
 	})
 	access, err := accessToken.SignedString([]byte(secretKey))
 	if err != nil {
@@ -27,7 +24,7 @@ func GenerateTokens(userRole string, userID uint) (string, string, error) {
 	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"user_role": userRole,
 		"id":        userID,
-		"exp":       time.Now().Add(12 * time.Hour).Unix(),
+		"exp":       time.Now().Add(config.Current.RefreshTokenTTL).Unix(),
 	})
 	refresh, err := refreshToken.SignedString([]byte(secretKey))
 	if err != nil {
@@ -38,10 +35,7 @@ func GenerateTokens(userRole string, userID uint) (string, string, error) {
 }
 
 func ValidateToken(tokenString string) (map[string]interface{}, error) {
-	secretKey := os.Getenv("enweyos")
-	if secretKey == "" {
-		secretKey = "enweyos"
-	}
+	secretKey := config.Current.JWTSecret
 
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -70,10 +64,7 @@ func ValidateToken(tokenString string) (map[string]interface{}, error) {
 }
 
 func RefreshTokens(oldRefreshToken string) (string, string, error) {
-	secretKey := os.Getenv("enweyos")
-	if secretKey == "" {
-		secretKey = "enweyos"
-	}
+	secretKey := config.Current.JWTSecret
 
 	token, err := jwt.Parse(oldRefreshToken, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -102,7 +93,7 @@ func RefreshTokens(oldRefreshToken string) (string, string, error) {
 		newAccessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 			"user_role": userRole,
 			"id":        uint(userID),
-			"exp":       time.Now().Add(15 * time.Minute).Unix(),
+			"exp":       time.Now().Add(config.Current.AccessTokenTTL).Unix(),
 		})
 		newAccess, err := newAccessToken.SignedString([]byte(secretKey))
 		if err != nil {
@@ -112,7 +103,7 @@ func RefreshTokens(oldRefreshToken string) (string, string, error) {
 		newRefreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 			"user_role": userRole,
 			"id":        uint(userID),
-			"exp":       time.Now().Add(12 * time.Hour).Unix(),
+			"exp":       time.Now().Add(config.Current.RefreshTokenTTL).Unix(),
 		})
 		newRefresh, err := newRefreshToken.SignedString([]byte(secretKey))
 		if err != nil {
@@ -1,9 +1,10 @@
 package utils
 
 import (
+	"cafe/apierr"
+	"cafe/logging"
 	"errors"
 	"github.com/gin-gonic/gin"
-	"net/http"
 	"strings"
 )
 
@@ -11,31 +12,28 @@ func CafeMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+			apierr.Respond(c, apierr.Unauthorized(apierr.CodeUnauthorized))
 			return
 		}
 
 		role, err := ExtractRoleFromToken(authHeader)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-			c.Abort()
+			apierr.Respond(c, apierr.Unauthorized(apierr.CodeInvalidToken))
 			return
 		}
 
 		if role != "cafe" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: Cafe access required"})
-			c.Abort()
+			apierr.Respond(c, apierr.Forbidden(apierr.CodeCafeAccessRequired))
 			return
 		}
 
 		userID, err := ExtractIDFromToken(authHeader)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token ID"})
-			c.Abort()
+			apierr.Respond(c, apierr.Unauthorized(apierr.CodeInvalidToken))
 			return
 		}
 		c.Set("user_id", userID)
+		logging.SetCafeID(c.Request.Context(), userID)
 
 		c.Next()
 	}